@@ -5,19 +5,139 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+
+	"github.com/bigkevmcd/interceptor/pkg/forks"
+	"github.com/bigkevmcd/interceptor/pkg/git"
 	"github.com/bigkevmcd/interceptor/pkg/interception"
+	"github.com/bigkevmcd/interceptor/pkg/interception/pullrequest"
+	"github.com/bigkevmcd/interceptor/pkg/logging"
+	"github.com/bigkevmcd/interceptor/pkg/metrics"
+	"github.com/bigkevmcd/interceptor/pkg/rules"
+	"github.com/bigkevmcd/interceptor/pkg/secrets"
+)
+
+const (
+	secretEnvVar      = "WEBHOOK_SECRET"
+	githubTokenEnvVar = "GITHUB_TOKEN"
 )
 
 var (
-	port = flag.Int("port", 8080, "port to listen on")
+	port        = flag.Int("port", 8080, "port to listen on")
+	secretFile  = flag.String("secret-file", "", "file containing the default secret used to verify hook signatures")
+	secretsFile = flag.String("secrets-file", "", "JSON file mapping repository names to secrets used to verify hook signatures")
+	configFile  = flag.String("config", "", "YAML file containing rules that are served from /rules/<name>")
+	configPoll  = flag.Duration("config-poll-interval", time.Second*10, "how often to check the config file for changes")
+	logLevel    = flag.String("log-level", "info", "level to log at, one of debug, info, warn or error")
+	shaLength   = flag.Int("short-sha-length", git.DefaultAbbrevLength, "length of the short SHAs added to intercepted hooks")
+	githubURL   = flag.String("github-api-url", forks.DefaultAPIURL, "URL of the GitHub API used to check the authors of pull requests from forks")
+	githubToken = flag.String("github-token-file", "", "file containing the token used to authenticate with the GitHub API")
 )
 
 func main() {
 	flag.Parse()
 
-	http.HandleFunc("/", interception.Handler)
+	logger, err := logging.New(*logLevel)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer logger.Sync()
+	logging.SetDefault(logger)
+
+	if err := git.CheckAbbrevLength(*shaLength); err != nil {
+		logger.Fatal("invalid short SHA length", zap.Error(err))
+	}
+
+	store, err := makeSecretStore()
+	if err != nil {
+		logger.Fatal("failed to load the secrets", zap.Error(err))
+	}
+
+	opts := []interception.Option{
+		interception.WithMetrics(metrics.New(prometheus.DefaultRegisterer)),
+		interception.WithShortSHALength(*shaLength),
+	}
+	if store.Empty() {
+		logger.Warn("no secrets configured, hook signatures will not be verified")
+	} else {
+		opts = append(opts, interception.WithSecrets(store))
+	}
+	checker, err := makeForkChecker()
+	if err != nil {
+		logger.Fatal("failed to create the GitHub client", zap.Error(err))
+	}
+	server := interception.NewServer(opts...)
+	registerInterceptors(server, checker)
+	hookHandler := server.ServeHTTP
+
+	http.Handle("/metrics", promhttp.Handler())
+	http.HandleFunc("/", hookHandler)
+	http.HandleFunc("/triggers", interception.TriggersHandler(hookHandler))
+	if *configFile != "" {
+		watcher, err := rules.NewWatcher(*configFile)
+		if err != nil {
+			logger.Fatal("failed to load the rules", zap.Error(err))
+		}
+		rs := watcher.RuleSet()
+		logger.Info("loaded rules", zap.Int("rules", rs.Len()),
+			zap.String("path", *configFile), zap.String("version", rs.Version()))
+		go watcher.Watch(*configPoll, make(chan struct{}))
+		http.HandleFunc(interception.RulesPath, interception.RulesHandler(watcher, hookHandler))
+	}
 	addr := fmt.Sprintf(":%d", *port)
-	log.Printf("Listening on %s\n", addr)
-	log.Fatal(http.ListenAndServe(addr, nil))
+	logger.Info("listening", zap.String("addr", addr))
+	logger.Fatal("server failed", zap.Error(http.ListenAndServe(addr, nil)))
+}
+
+// registerInterceptors registers the built-in Interceptors for each of the
+// providers, the checker is used for GitHub pull requests from forks.
+func registerInterceptors(s *interception.Server, checker *forks.Checker) {
+	interception.RegisterDefaults(s)
+	s.Register(interception.GitHubProvider, "pull_request", pullrequest.NewInterceptor(checker))
+}
+
+// makeSecretStore creates a secret store from the default secret (read from
+// the secret-file or the WEBHOOK_SECRET environment variable), and the
+// per-repository secrets in the secrets-file.
+func makeSecretStore() (*secrets.Store, error) {
+	defaultSecret := secrets.FromEnv(secretEnvVar)
+	if *secretFile != "" {
+		s, err := secrets.FromFile(*secretFile)
+		if err != nil {
+			return nil, err
+		}
+		defaultSecret = s
+	}
+	var repoSecrets map[string][]byte
+	if *secretsFile != "" {
+		s, err := secrets.RepoSecretsFromFile(*secretsFile)
+		if err != nil {
+			return nil, err
+		}
+		repoSecrets = s
+	}
+	return secrets.New(defaultSecret, repoSecrets), nil
+}
+
+// makeForkChecker creates a checker for the GitHub API at the github-api-url,
+// authenticated with the token from the github-token-file or the
+// GITHUB_TOKEN environment variable.
+func makeForkChecker() (*forks.Checker, error) {
+	token := secrets.FromEnv(githubTokenEnvVar)
+	if *githubToken != "" {
+		t, err := secrets.FromFile(*githubToken)
+		if err != nil {
+			return nil, err
+		}
+		token = t
+	}
+	client, err := forks.NewClient(*githubURL, string(token))
+	if err != nil {
+		return nil, err
+	}
+	return forks.NewChecker(client), nil
 }
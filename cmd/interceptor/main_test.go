@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bigkevmcd/interceptor/pkg/interception"
+	"github.com/bigkevmcd/interceptor/pkg/interception/pullrequest"
+)
+
+func TestRegisterInterceptors(t *testing.T) {
+	s := interception.NewServer()
+	registerInterceptors(s, nil)
+
+	if _, ok := s.Lookup(interception.GitLabProvider, "Push Hook"); !ok {
+		t.Error("the default Interceptors were not registered")
+	}
+	i, ok := s.Lookup(interception.GitHubProvider, "pull_request")
+	if _, isForks := i.(*pullrequest.Interceptor); !ok || !isForks {
+		t.Errorf("got %T for GitHub pull_request events, wanted a *pullrequest.Interceptor", i)
+	}
+}
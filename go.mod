@@ -1,11 +1,37 @@
 module github.com/bigkevmcd/interceptor
 
-go 1.13
+go 1.21
 
 require (
-	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/Masterminds/semver/v3 v3.4.0
+	github.com/google/cel-go v0.12.6
 	github.com/google/go-github/v28 v28.1.1
-	github.com/stretchr/testify v1.3.0 // indirect
+	github.com/prometheus/client_golang v1.7.1
 	github.com/tidwall/gjson v1.3.5
 	github.com/tidwall/sjson v1.0.4
+	go.uber.org/zap v1.15.0
+	google.golang.org/protobuf v1.28.0
+	sigs.k8s.io/yaml v1.2.0
+)
+
+require (
+	github.com/antlr/antlr4/runtime/Go/antlr v0.0.0-20220418222510-f25a4f6275ed // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.1.1 // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/google/go-querystring v1.0.0 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
+	github.com/prometheus/client_model v0.2.0 // indirect
+	github.com/prometheus/common v0.10.0 // indirect
+	github.com/prometheus/procfs v0.1.3 // indirect
+	github.com/stoewer/go-strcase v1.2.0 // indirect
+	github.com/tidwall/match v1.0.1 // indirect
+	github.com/tidwall/pretty v1.0.0 // indirect
+	go.uber.org/atomic v1.6.0 // indirect
+	go.uber.org/multierr v1.5.0 // indirect
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9 // indirect
+	golang.org/x/sys v0.0.0-20210510120138-977fb7262007 // indirect
+	golang.org/x/text v0.3.7 // indirect
+	google.golang.org/genproto v0.0.0-20220502173005-c8bf987b8c21 // indirect
+	gopkg.in/yaml.v2 v2.2.8 // indirect
 )
@@ -0,0 +1,90 @@
+// Package cache provides a size-limited least recently used cache, with
+// optional expiry of the values.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is a cache that holds a maximum number of values, when it's full, the
+// least recently used value is evicted to make room for new values.
+//
+// It's safe for concurrent use.
+type LRU struct {
+	size  int
+	ttl   time.Duration
+	now   func() time.Time
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// New creates an LRU that holds up to size values, the size must be greater
+// than zero.
+func New(size int) *LRU {
+	return NewTTL(size, 0)
+}
+
+// NewTTL creates an LRU that holds up to size values, and values expire
+// once they have been in the cache for the ttl, if the ttl is zero, values
+// don't expire.
+func NewTTL(size int, ttl time.Duration) *LRU {
+	if size <= 0 {
+		panic("cache: size must be greater than zero")
+	}
+	return &LRU{size: size, ttl: ttl, now: time.Now, ll: list.New(), items: map[string]*list.Element{}}
+}
+
+// Get returns the value for the key, and true if it was in the cache and
+// has not expired.
+func (c *LRU) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if c.ttl > 0 && !c.now().Before(e.expires) {
+		c.ll.Remove(el)
+		delete(c.items, key)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return e.value, true
+}
+
+// Add adds a value to the cache, replacing any existing value for the key.
+func (c *LRU) Add(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := c.now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expires = value, expires
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&entry{key: key, value: value, expires: expires})
+	if c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry).key)
+	}
+}
+
+// Len returns the number of values in the cache, including any that have
+// expired, but have not been removed yet.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	c := New(2)
+	c.Add("a", 1)
+	c.Add("b", 2)
+
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) got %v, %v, wanted 1, true", v, ok)
+	}
+	// "b" is now the least recently used.
+	c.Add("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("Get(b) found an evicted value")
+	}
+	for k, want := range map[string]int{"a": 1, "c": 3} {
+		if v, ok := c.Get(k); !ok || v != want {
+			t.Errorf("Get(%s) got %v, %v, wanted %d, true", k, v, ok, want)
+		}
+	}
+	if l := c.Len(); l != 2 {
+		t.Fatalf("Len() got %d, wanted 2", l)
+	}
+}
+
+func TestLRUReplacesValues(t *testing.T) {
+	c := New(2)
+	c.Add("a", 1)
+	c.Add("a", 2)
+
+	if v, ok := c.Get("a"); !ok || v != 2 {
+		t.Fatalf("Get(a) got %v, %v, wanted 2, true", v, ok)
+	}
+	if l := c.Len(); l != 1 {
+		t.Fatalf("Len() got %d, wanted 1", l)
+	}
+}
+
+func TestLRUConcurrentUse(t *testing.T) {
+	c := New(10)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprintf("%d-%d", i, j)
+				c.Add(key, j)
+				c.Get(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if l := c.Len(); l != 10 {
+		t.Fatalf("Len() got %d, wanted 10", l)
+	}
+}
+
+func TestNewWithInvalidSize(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("New(0) did not panic")
+		}
+	}()
+	New(0)
+}
+
+func TestLRUWithTTL(t *testing.T) {
+	now := time.Date(2026, time.October, 18, 10, 0, 0, 0, time.UTC)
+	c := NewTTL(2, time.Minute)
+	c.now = func() time.Time { return now }
+	c.Add("a", 1)
+
+	now = now.Add(30 * time.Second)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) got %v, %v, wanted 1, true", v, ok)
+	}
+	c.Add("b", 2)
+
+	now = now.Add(30 * time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("Get(a) found an expired value")
+	}
+	if v, ok := c.Get("b"); !ok || v != 2 {
+		t.Fatalf("Get(b) got %v, %v, wanted 2, true", v, ok)
+	}
+	if l := c.Len(); l != 1 {
+		t.Fatalf("Len() got %d, wanted 1", l)
+	}
+}
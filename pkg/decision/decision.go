@@ -0,0 +1,141 @@
+package decision
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/bigkevmcd/interceptor/pkg/event"
+)
+
+// NotMatched is the reason when an interceptor rejects a hook without
+// providing a reason.
+const NotMatched Reason = "not_matched"
+
+// DefaultKey is the key that intercepted values are added to the body as.
+const DefaultKey = "intercepted"
+
+var keyRE = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_-]*$")
+
+// ValidKey returns true if the key can be used as the Key for a Decision,
+// keys must start with a letter or "_", and can only contain letters,
+// digits, "_" and "-", so that they're a single key in the body.
+func ValidKey(key string) bool {
+	return keyRE.MatchString(key)
+}
+
+// Decision is the result of intercepting a hook.
+type Decision struct {
+	// Allow is true if the hook should be passed on.
+	Allow bool
+	// Reason is the machine-readable reason for the decision.
+	Reason Reason
+	// Message is an optional human-readable explanation of the decision.
+	Message string
+	// Intercepted are values that are added to the hook body as
+	// "intercepted".
+	Intercepted map[string]interface{}
+	// Key, if set, is the key that the intercepted values are added to the
+	// body as, instead of "intercepted".
+	Key string
+	// Event is the normalised event that the decision was made for, if the
+	// Interceptor provides one.
+	Event *event.Event
+	// Body, if set, replaces the hook body in the response.
+	Body []byte
+	// Status is an optional HTTP status for the response, if it's zero, the
+	// default status for the decision is used.
+	Status int
+}
+
+// Allowed returns a Decision that allows the hook, adding the intercepted
+// values to the body.
+func Allowed(intercepted map[string]interface{}) *Decision {
+	return &Decision{Allow: true, Reason: Matched, Intercepted: intercepted}
+}
+
+// AllowedEvent returns a Decision that allows the hook, adding the
+// intercepted values to the body, with the normalised event that the
+// decision was made for.
+func AllowedEvent(e *event.Event, intercepted map[string]interface{}) *Decision {
+	d := Allowed(intercepted)
+	d.Event = e
+	return d
+}
+
+// Denied returns a Decision that rejects the hook for the reason.
+func Denied(reason Reason) *Decision {
+	return &Decision{Reason: reason}
+}
+
+// Apply returns the body for an allowed hook, with the intercepted values
+// added, or nil if the hook is not allowed.
+//
+// If the body already has an "intercepted" object, the values are merged
+// into it, replacing any existing values with the same keys.
+//
+// If the decision has a Key, this is used instead of "intercepted", and any
+// "intercepted" values already in the body, e.g. from an InterceptionFunc,
+// are moved to the Key.
+func (d *Decision) Apply(body []byte) ([]byte, error) {
+	if !d.Allow {
+		return nil, nil
+	}
+	if d.Body != nil {
+		body = d.Body
+	}
+	moved := d.key() != DefaultKey && gjson.GetBytes(body, DefaultKey).Exists()
+	if d.Intercepted == nil && !moved {
+		return body, nil
+	}
+	values := d.Values(body)
+	if moved {
+		var err error
+		body, err = sjson.DeleteBytes(body, DefaultKey)
+		if err != nil {
+			return nil, fmt.Errorf("error moving the intercepted values: %w", err)
+		}
+	}
+	updated, err := sjson.SetBytes(body, d.key(), values)
+	if err != nil {
+		return nil, fmt.Errorf("error setting the intercepted values: %w", err)
+	}
+	return updated, nil
+}
+
+// Values returns the intercepted values that Apply adds to the body, these
+// are the values already in the body, with the decision's values merged
+// into them.
+//
+// If the decision has a Key, the values already in the body as
+// "intercepted" are merged into the values already in the body as the Key.
+func (d *Decision) Values(body []byte) map[string]interface{} {
+	if d.Body != nil {
+		body = d.Body
+	}
+	values := map[string]interface{}{}
+	keys := []string{d.key()}
+	if d.key() != DefaultKey {
+		keys = append(keys, DefaultKey)
+	}
+	for _, key := range keys {
+		if existing, ok := gjson.GetBytes(body, key).Value().(map[string]interface{}); ok {
+			for k, v := range existing {
+				values[k] = v
+			}
+		}
+	}
+	for k, v := range d.Intercepted {
+		values[k] = v
+	}
+	return values
+}
+
+func (d *Decision) key() string {
+	if d.Key == "" {
+		return DefaultKey
+	}
+	return d.Key
+}
@@ -0,0 +1,83 @@
+package decision
+
+import (
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	applyTests := []struct {
+		name     string
+		decision *Decision
+		want     string
+	}{
+		{"denied", Denied(RepoMismatch), ""},
+		{"allowed", Allowed(nil), `{"ref":"master"}`},
+		{"allowed with values", Allowed(map[string]interface{}{"fullname": "testing/testing"}),
+			`{"intercepted":{"fullname":"testing/testing"},"ref":"master"}`},
+		{"replaced body", &Decision{Allow: true, Body: []byte(`testing`)}, `testing`},
+		{"merged values", &Decision{Allow: true,
+			Body:        []byte(`{"intercepted":{"ref":"main","short_sha":"1234567"}}`),
+			Intercepted: map[string]interface{}{"ref": "develop", "draft": false}},
+			`{"intercepted":{"draft":false,"ref":"develop","short_sha":"1234567"}}`},
+		{"with key", &Decision{Allow: true, Key: "build",
+			Intercepted: map[string]interface{}{"fullname": "testing/testing"}},
+			`{"build":{"fullname":"testing/testing"},"ref":"master"}`},
+		{"moved to key", &Decision{Allow: true, Key: "build",
+			Body: []byte(`{"intercepted":{"short_sha":"1234567"},"ref":"master"}`)},
+			`{"build":{"short_sha":"1234567"},"ref":"master"}`},
+		{"merged and moved to key", &Decision{Allow: true, Key: "build",
+			Body:        []byte(`{"build":{"ref":"main"},"intercepted":{"short_sha":"1234567"}}`),
+			Intercepted: map[string]interface{}{"ref": "develop"}},
+			`{"build":{"ref":"develop","short_sha":"1234567"}}`},
+		{"key with no values", &Decision{Allow: true, Key: "build"}, `{"ref":"master"}`},
+	}
+
+	for _, tt := range applyTests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.decision.Apply([]byte(`{"ref":"master"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Fatalf("Apply() got %s, wanted %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidKey(t *testing.T) {
+	keyTests := []struct {
+		key  string
+		want bool
+	}{
+		{"intercepted", true},
+		{"build_info", true},
+		{"build-info", true},
+		{"", false},
+		{"build.info", false},
+		{"1build", false},
+	}
+
+	for _, tt := range keyTests {
+		if got := ValidKey(tt.key); got != tt.want {
+			t.Errorf("ValidKey(%q) got %v, wanted %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+// Before the CEL overlays were added, Apply replaced an existing
+// "intercepted" object in the body, values from earlier interceptors are now
+// kept.
+func TestApplyKeepsExistingValues(t *testing.T) {
+	body := []byte(`{"intercepted":{"pipeline":"build","ref":"main"},"ref":"main"}`)
+	d := Allowed(map[string]interface{}{"ref": "develop"})
+
+	b, err := d.Apply(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"intercepted":{"pipeline":"build","ref":"develop"},"ref":"main"}`
+	if string(b) != want {
+		t.Fatalf("Apply() got %s, wanted %s", b, want)
+	}
+}
@@ -0,0 +1,91 @@
+package decision
+
+// Reason is a machine-readable reason for an interception decision.
+type Reason string
+
+// These are the reasons for the interception decisions.
+const (
+	// Matched is the reason when the hook matches.
+	Matched Reason = "matched"
+	// EventMismatch is the reason when the hook is not the expected event.
+	EventMismatch Reason = "event_mismatch"
+	// RepoMismatch is the reason when the hook is for a different repo.
+	RepoMismatch Reason = "repo_mismatch"
+	// ActionNotInList is the reason when the hook's action is not one of
+	// the requested actions.
+	ActionNotInList Reason = "action_not_in_list"
+	// RefNotIncluded is the reason when the hook's ref doesn't match the
+	// requested refs.
+	RefNotIncluded Reason = "ref_not_included"
+	// RefExcluded is the reason when the hook's ref matches the excluded
+	// refs.
+	RefExcluded Reason = "ref_excluded"
+	// NotTag is the reason when a tag was requested, but the hook is not for
+	// a tag.
+	NotTag Reason = "not_tag"
+	// TagNotIncluded is the reason when the tag doesn't match the requested
+	// tags.
+	TagNotIncluded Reason = "tag_not_included"
+	// NotSemver is the reason when a version constraint was requested, but
+	// the tag is not a semantic version.
+	NotSemver Reason = "not_semver"
+	// VersionOutOfRange is the reason when the tag's version doesn't satisfy
+	// the requested constraint.
+	VersionOutOfRange Reason = "version_out_of_range"
+	// PathsNotMatched is the reason when none of the changed files match the
+	// requested paths.
+	PathsNotMatched Reason = "paths_not_matched"
+	// CommentNotCreated is the reason when a comment was edited or deleted.
+	CommentNotCreated Reason = "comment_not_created"
+	// NotPullRequest is the reason when a comment is not on a pull request.
+	NotPullRequest Reason = "not_pull_request"
+	// AuthorNotAllowed is the reason when the commenter's association is not
+	// one of the allowed associations.
+	AuthorNotAllowed Reason = "author_not_allowed"
+	// CommandNotFound is the reason when a comment has none of the requested
+	// commands.
+	CommandNotFound Reason = "command_not_found"
+	// SenderNotAllowed is the reason when the user that triggered the event
+	// is not one of the requested senders.
+	SenderNotAllowed Reason = "sender_not_allowed"
+	// BaseRefNotIncluded is the reason when a pull request's base branch
+	// doesn't match any of the requested patterns.
+	BaseRefNotIncluded Reason = "base_ref_not_included"
+	// HeadRefNotIncluded is the reason when a pull request's head branch
+	// doesn't match any of the requested patterns.
+	HeadRefNotIncluded Reason = "head_ref_not_included"
+	// DraftPullRequest is the reason when a pull request is a draft, and
+	// drafts are not included.
+	DraftPullRequest Reason = "draft_pull_request"
+	// LabelNotIncluded is the reason when a pull request has none of the
+	// requested labels.
+	LabelNotIncluded Reason = "label_not_included"
+	// LabelExcluded is the reason when a pull request has one of the
+	// excluded labels.
+	LabelExcluded Reason = "label_excluded"
+	// LabelChangeIgnored is the reason when the label that was added or
+	// removed is not one of the requested or excluded labels.
+	LabelChangeIgnored Reason = "label_change_ignored"
+	// SkipMarker is the reason when a commit message or pull request has a
+	// marker like "[skip ci]".
+	SkipMarker Reason = "skip_marker"
+	// RefCreated is the reason when a push created a ref, and creations
+	// are not matched.
+	RefCreated Reason = "ref_created"
+	// RefDeleted is the reason when a push deleted a ref, and deletions are
+	// not matched.
+	RefDeleted Reason = "ref_deleted"
+	// ForcedPush is the reason when a push was a force push, and force
+	// pushes are not matched.
+	ForcedPush Reason = "forced_push"
+	// FilterRejected is the reason when the Interceptor-Filter expression
+	// returned false.
+	FilterRejected Reason = "filter_rejected"
+	// UntrustedFork is the reason when a pull request from a fork was
+	// opened by a user that is not a collaborator or organisation member.
+	UntrustedFork Reason = "untrusted_fork"
+	// OKToTestRequired is the reason when a pull request from a fork was
+	// opened by an untrusted user, and no trusted user has commented
+	// "/ok-to-test".
+	OKToTestRequired Reason = "ok_to_test_required"
+)
@@ -0,0 +1,91 @@
+package event
+
+import "github.com/bigkevmcd/interceptor/pkg/git"
+
+// These are the types of event that can be matched.
+const (
+	Push         = "push"
+	PullRequest  = "pull_request"
+	IssueComment = "issue_comment"
+)
+
+// Event is a hook event from any provider, normalised so that it can be
+// matched in the same way regardless of where it came from.
+type Event struct {
+	// Type is the type of event, e.g. Push or PullRequest.
+	Type string
+	// Repo is the full name of the repository e.g. "tektoncd/triggers".
+	Repo string
+	// Ref is the branch for pushes to branches, or the full ref for other
+	// pushes e.g. "refs/tags/v1.0.0".
+	Ref string
+	// Action is the action for pull request events e.g. "opened".
+	Action string
+	// Sender is the login of the user that triggered the event.
+	Sender string
+	// SHA is the head commit of a push or pull request.
+	SHA string
+
+	// HeadCommitMessage is the message of the head commit of a push.
+	HeadCommitMessage string
+	// CommitMessages are the messages of all the commits in a push.
+	CommitMessages []string
+	// Before is the SHA that the ref pointed to before a push, this is all
+	// zeros if the ref was created.
+	Before string
+	// After is the SHA that the ref points to after a push, this is all
+	// zeros if the ref was deleted.
+	After string
+	// Created is true if a push created the ref.
+	Created bool
+	// Deleted is true if a push deleted the ref.
+	Deleted bool
+	// Forced is true if a push was a force push.
+	Forced bool
+
+	// These are only set for pull request and issue comment events.
+
+	// Number is the pull request number.
+	Number int
+	// Author is the login of the user that opened the pull request.
+	Author string
+	// Title is the title of the pull request.
+	Title string
+	// Description is the body of the pull request.
+	Description string
+	// BaseRef is the branch that the pull request will be merged into.
+	BaseRef string
+	// HeadRef is the branch with the pull request changes.
+	HeadRef string
+	// HeadRepo is the full name of the repository that HeadRef is in, this
+	// is empty if the repository has been deleted.
+	HeadRepo string
+	// Draft is true if the pull request is a draft.
+	Draft bool
+	// Labels are the names of the labels on the pull request.
+	Labels []string
+	// Label is the name of the label that was added or removed, for
+	// "labeled" and "unlabeled" events.
+	Label string
+}
+
+// IsFork returns true if the changes in a pull request come from a different
+// repository, if the head repository has been deleted, it's treated as a
+// fork.
+func (e *Event) IsFork() bool {
+	return e.HeadRepo != e.Repo
+}
+
+// BranchName returns the branch for pushes to branches, and the head branch
+// for pull requests, it's empty for other events, and pushes to tags.
+func (e *Event) BranchName() string {
+	switch e.Type {
+	case PullRequest:
+		return e.HeadRef
+	case Push:
+		if r := git.ParseRef(e.Ref); r.Kind == git.Branch || r.Kind == git.Other {
+			return r.Short
+		}
+	}
+	return ""
+}
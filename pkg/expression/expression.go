@@ -0,0 +1,171 @@
+// Package expression evaluates CEL expressions over hook bodies and headers.
+package expression
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/bigkevmcd/interceptor/pkg/cache"
+)
+
+// Evaluator evaluates CEL expressions, with the hook body available as
+// "body" and the request headers as "header".
+//
+// The headers are a map of the canonical header names to the values, joined
+// with ", " if there are multiple values.
+//
+// Expressions are compiled the first time they are evaluated, and up to
+// CacheSize of the compiled programs are cached.
+type Evaluator struct {
+	env      *cel.Env
+	programs *cache.LRU
+}
+
+// CacheSize is the number of compiled programs that an Evaluator caches, the
+// expressions come from request headers, so the cache is bounded.
+const CacheSize = 500
+
+// New creates an Evaluator.
+func New() (*Evaluator, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("body", cel.DynType),
+		cel.Variable("header", cel.MapType(cel.StringType, cel.StringType)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the CEL environment: %w", err)
+	}
+	return &Evaluator{env: env, programs: cache.New(CacheSize)}, nil
+}
+
+// MustNew creates an Evaluator and panics if it fails.
+func MustNew() *Evaluator {
+	e, err := New()
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// Input is a decoded hook body and the request headers, decode the body once
+// with NewInput to evaluate several expressions over the same hook.
+type Input struct {
+	vars map[string]interface{}
+}
+
+// NewInput decodes the body, and prepares the headers for evaluation.
+func NewInput(body []byte, h http.Header) (*Input, error) {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to parse the body: %w", err)
+	}
+	return &Input{vars: map[string]interface{}{
+		"body":   decoded,
+		"header": headerMap(h),
+	}}, nil
+}
+
+// Filter evaluates an expression that must return a bool.
+func (e *Evaluator) Filter(expr string, body []byte, h http.Header) (bool, error) {
+	in, err := NewInput(body, h)
+	if err != nil {
+		return false, err
+	}
+	return e.FilterInput(expr, in)
+}
+
+// FilterInput is like Filter, but with an already decoded Input.
+func (e *Evaluator) FilterInput(expr string, in *Input) (bool, error) {
+	val, err := e.eval(expr, in)
+	if err != nil {
+		return false, err
+	}
+	b, ok := val.(types.Bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q returned %s, not a bool", expr, val.Type().TypeName())
+	}
+	return bool(b), nil
+}
+
+// Evaluate evaluates an expression, and returns the result as the
+// equivalent JSON value.
+func (e *Evaluator) Evaluate(expr string, body []byte, h http.Header) (interface{}, error) {
+	in, err := NewInput(body, h)
+	if err != nil {
+		return nil, err
+	}
+	return e.EvaluateInput(expr, in)
+}
+
+// EvaluateInput is like Evaluate, but with an already decoded Input.
+func (e *Evaluator) EvaluateInput(expr string, in *Input) (interface{}, error) {
+	val, err := e.eval(expr, in)
+	if err != nil {
+		return nil, err
+	}
+	native, err := val.ConvertToNative(reflect.TypeOf(&structpb.Value{}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the result of expression %q: %w", expr, err)
+	}
+	b, err := protojson.Marshal(native.(*structpb.Value))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the result of expression %q: %w", expr, err)
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("failed to convert the result of expression %q: %w", expr, err)
+	}
+	return v, nil
+}
+
+func (e *Evaluator) eval(expr string, in *Input) (ref.Val, error) {
+	prg, err := e.program(expr)
+	if err != nil {
+		return nil, err
+	}
+	out, _, err := prg.Eval(in.vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate expression %q: %w", expr, err)
+	}
+	return out, nil
+}
+
+// program returns the compiled program for the expression, compiling it if
+// it's not in the cache.
+func (e *Evaluator) program(expr string) (cel.Program, error) {
+	if prg, ok := e.programs.Get(expr); ok {
+		return prg.(cel.Program), nil
+	}
+	ast, issues := e.env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed to compile expression %q: %w", expr, issues.Err())
+	}
+	prg, err := e.env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile expression %q: %w", expr, err)
+	}
+	e.programs.Add(expr, prg)
+	return prg, nil
+}
+
+// Check compiles the expression, and returns any errors.
+func (e *Evaluator) Check(expr string) error {
+	_, err := e.program(expr)
+	return err
+}
+
+func headerMap(h http.Header) map[string]string {
+	m := make(map[string]string, len(h))
+	for k, v := range h {
+		m[http.CanonicalHeaderKey(k)] = strings.Join(v, ", ")
+	}
+	return m
+}
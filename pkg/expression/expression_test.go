@@ -0,0 +1,142 @@
+package expression
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+const testBody = `{"pull_request":{"base":{"ref":"main"},"draft":false,"number":12},"repository":{"full_name":"testing/testing"}}`
+
+func TestFilter(t *testing.T) {
+	e := MustNew()
+	h := http.Header{"X-Github-Event": []string{"pull_request"}}
+	filterTests := []struct {
+		expr string
+		want bool
+	}{
+		{"body.pull_request.base.ref == 'main' && !body.pull_request.draft", true},
+		{"body.pull_request.base.ref == 'develop'", false},
+		{"header['X-Github-Event'] == 'pull_request'", true},
+		{"body.repository.full_name.startsWith('testing/')", true},
+	}
+
+	for _, tt := range filterTests {
+		t.Run(tt.expr, func(t *testing.T) {
+			ok, err := e.Filter(tt.expr, []byte(testBody), h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.want {
+				t.Fatalf("Filter() got %v, wanted %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	e := MustNew()
+	errorTests := []struct {
+		name string
+		expr string
+		body string
+	}{
+		{"invalid expression", "body.pull_request ==", testBody},
+		{"not a bool", "body.pull_request.number", testBody},
+		{"missing key", "body.push.ref == 'main'", testBody},
+		{"invalid body", "true", `{`},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := e.Filter(tt.expr, []byte(tt.body), nil); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	e := MustNew()
+	evaluateTests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"body.pull_request.base.ref", "main"},
+		{"body.pull_request.number + 1.0", 13.0},
+		{"body.pull_request.draft", false},
+		{"[body.pull_request.base.ref, header['X-Github-Event']]", []interface{}{"main", "push"}},
+		{"{'ref': body.pull_request.base.ref}", map[string]interface{}{"ref": "main"}},
+	}
+
+	for _, tt := range evaluateTests {
+		t.Run(tt.expr, func(t *testing.T) {
+			v, err := e.Evaluate(tt.expr, []byte(testBody), http.Header{"X-Github-Event": []string{"push"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(v, tt.want) {
+				t.Fatalf("Evaluate() got %#v, wanted %#v", v, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgramsAreCached(t *testing.T) {
+	e := MustNew()
+	expr := "body.pull_request.draft == false"
+
+	for i := 0; i < 2; i++ {
+		if _, err := e.Filter(expr, []byte(testBody), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if count := e.programs.Len(); count != 1 {
+		t.Fatalf("got %d cached programs, wanted 1", count)
+	}
+}
+
+func TestProgramCacheIsBounded(t *testing.T) {
+	e := MustNew()
+
+	for i := 0; i < CacheSize+10; i++ {
+		if err := e.Check(fmt.Sprintf("body.pull_request.number == %d.0", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if count := e.programs.Len(); count != CacheSize {
+		t.Fatalf("got %d cached programs, wanted %d", count, CacheSize)
+	}
+}
+
+func TestInput(t *testing.T) {
+	e := MustNew()
+	in, err := NewInput([]byte(testBody), http.Header{"X-Github-Event": []string{"pull_request"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := e.FilterInput("header['X-Github-Event'] == 'pull_request'", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("FilterInput() got false, wanted true")
+	}
+	v, err := e.EvaluateInput("body.pull_request.base.ref", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "main" {
+		t.Fatalf("EvaluateInput() got %#v, wanted %#v", v, "main")
+	}
+}
+
+func TestNewInputWithInvalidBody(t *testing.T) {
+	if _, err := NewInput([]byte(`{`), nil); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,247 @@
+// Package forks checks whether pull requests from forks were opened by users
+// that are trusted to run pipelines, by querying the GitHub API.
+package forks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+
+	"github.com/bigkevmcd/interceptor/pkg/cache"
+)
+
+const (
+	// PolicyHeader configures what happens to pull requests from forks
+	// that were opened by untrusted users, see ParsePolicy.
+	PolicyHeader = "Pullrequest-Fork-Policy"
+
+	// DefaultAPIURL is the URL of the public GitHub API.
+	DefaultAPIURL = "https://api.github.com/"
+
+	// OKToTestCommand is the command that a trusted user comments on a pull
+	// request from an untrusted fork, followed by the head SHA, to allow its
+	// pipelines to run.
+	OKToTestCommand = "/ok-to-test"
+
+	// MinApprovalSHALength is the shortest abbreviation of the head SHA that
+	// must follow the OKToTestCommand, shorter abbreviations are too easy to
+	// collide with.
+	MinApprovalSHALength = 12
+
+	// CacheSize is the number of API results that a Checker caches.
+	CacheSize = 1000
+	// CacheTTL is how long a Checker caches API results for, so changes to
+	// the collaborators on a repository can take this long to apply.
+	CacheTTL = 5 * time.Minute
+)
+
+// Policy is what happens to pull requests from forks that were opened by
+// untrusted users.
+type Policy string
+
+// These are the supported policies.
+const (
+	// Allow matches pull requests from forks without checking the author,
+	// this is the default.
+	Allow Policy = "allow"
+	// Reject rejects pull requests from forks unless the author is a
+	// collaborator on the repository, or a member of the organisation that
+	// owns it.
+	Reject Policy = "reject"
+	// OKToTest is like Reject, but also matches pull requests where a
+	// trusted user has commented OKToTestCommand with the head SHA.
+	OKToTest Policy = "ok-to-test"
+)
+
+// trustedAssociations are the author associations of the users whose
+// OKToTestCommand comments are trusted.
+var trustedAssociations = []string{"OWNER", "MEMBER", "COLLABORATOR"}
+
+// ParsePolicy parses a policy, an empty string is Allow.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(strings.TrimSpace(s)); p {
+	case "":
+		return Allow, nil
+	case Allow, Reject, OKToTest:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown fork policy %q, must be one of %q, %q or %q", s, Allow, Reject, OKToTest)
+}
+
+// FromRequest parses the policy from the PolicyHeader.
+func FromRequest(r *http.Request) (Policy, error) {
+	return ParsePolicy(r.Header.Get(PolicyHeader))
+}
+
+// RequireAllow returns an error if the request configures a policy other
+// than Allow, this is for providers where the authors of pull requests from
+// forks can't be checked, so that these aren't silently allowed.
+func RequireAllow(r *http.Request, provider string) error {
+	policy, err := FromRequest(r)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", PolicyHeader, err)
+	}
+	if policy != Allow {
+		return fmt.Errorf("fork policy %q is not supported for provider %s", policy, provider)
+	}
+	return nil
+}
+
+// NewClient creates a GitHub client for the API at baseURL, this can be a
+// GitHub Enterprise URL e.g. "https://github.example.com/api/v3/".
+//
+// If a token is provided, it's used to authenticate the requests, this needs
+// push access to the repositories to check for collaborators.
+func NewClient(baseURL, token string) (*github.Client, error) {
+	httpClient := &http.Client{}
+	if token != "" {
+		httpClient.Transport = &tokenTransport{token: token, next: http.DefaultTransport}
+	}
+	client, err := github.NewEnterpriseClient(baseURL, baseURL, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub API URL %q: %w", baseURL, err)
+	}
+	return client, nil
+}
+
+// Checker checks whether users are trusted to run pipelines for pull
+// requests from forks.
+//
+// The results of the API calls are cached for the CacheTTL.
+type Checker struct {
+	client *github.Client
+	cache  *cache.LRU
+}
+
+// NewChecker creates a Checker that queries the API with the client.
+func NewChecker(client *github.Client) *Checker {
+	return &Checker{client: client, cache: cache.NewTTL(CacheSize, CacheTTL)}
+}
+
+// Trusted returns true if the user is a collaborator on the repository, or a
+// member of the organisation that owns it.
+func (c *Checker) Trusted(ctx context.Context, repo, user string) (bool, error) {
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return false, err
+	}
+	if user == "" {
+		return false, nil
+	}
+	key := "trusted:" + repo + "/" + user
+	if v, ok := c.cache.Get(key); ok {
+		return v.(bool), nil
+	}
+	trusted, err := c.trusted(ctx, owner, name, user)
+	if err != nil {
+		return false, err
+	}
+	c.cache.Add(key, trusted)
+	return trusted, nil
+}
+
+func (c *Checker) trusted(ctx context.Context, owner, name, user string) (bool, error) {
+	repo := owner + "/" + name
+	collaborator, _, err := c.client.Repositories.IsCollaborator(ctx, owner, name, user)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if %s is a collaborator on %s: %w", user, repo, err)
+	}
+	if collaborator {
+		return true, nil
+	}
+	member, _, err := c.client.Organizations.IsMember(ctx, owner, user)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if %s is a member of %s: %w", user, owner, err)
+	}
+	return member, nil
+}
+
+// OKToTest returns true if a trusted user has approved the head commit of
+// the pull request by commenting OKToTestCommand followed by the SHA, or an
+// abbreviation of at least MinApprovalSHALength characters, e.g.
+// "/ok-to-test 6a6bcddc365c", the command must be at the start of a line in
+// the comment.
+//
+// The SHA is required because commit dates are set by the author of the
+// commit, so they can't show whether a commit was pushed before or after the
+// comment, commits pushed after the approval have to be approved again.
+//
+// Comments are trusted if the commenter is the owner of the repository, a
+// member of the organisation, or a collaborator.
+//
+// Only approvals are cached, so that new approvals apply immediately.
+func (c *Checker) OKToTest(ctx context.Context, repo string, number int, sha string) (bool, error) {
+	owner, name, err := splitRepo(repo)
+	if err != nil {
+		return false, err
+	}
+	key := fmt.Sprintf("ok-to-test:%s#%d@%s", repo, number, sha)
+	if _, ok := c.cache.Get(key); ok {
+		return true, nil
+	}
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := c.client.Issues.ListComments(ctx, owner, name, number, opts)
+		if err != nil {
+			return false, fmt.Errorf("failed to list the comments on %s#%d: %w", repo, number, err)
+		}
+		for _, comment := range comments {
+			if contains(trustedAssociations, comment.GetAuthorAssociation()) && approves(comment.GetBody(), sha) {
+				c.cache.Add(key, true)
+				return true, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return false, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// approves returns true if a line in the comment starts with the
+// OKToTestCommand followed by an abbreviation of the SHA.
+func approves(body, sha string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != OKToTestCommand {
+			continue
+		}
+		if len(fields[1]) >= MinApprovalSHALength && strings.HasPrefix(sha, fields[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitRepo(repo string) (string, string, error) {
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid repository name %q", repo)
+	}
+	return parts[0], parts[1], nil
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenTransport authenticates requests with a GitHub token.
+type tokenTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "token "+t.token)
+	return t.next.RoundTrip(r)
+}
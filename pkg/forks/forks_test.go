@@ -0,0 +1,352 @@
+package forks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	policyTests := []struct {
+		value   string
+		want    Policy
+		wantErr string
+	}{
+		{"", Allow, ""},
+		{"allow", Allow, ""},
+		{"reject", Reject, ""},
+		{" ok-to-test ", OKToTest, ""},
+		{"never", "", `unknown fork policy "never"`},
+	}
+
+	for _, tt := range policyTests {
+		p, err := ParsePolicy(tt.value)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParsePolicy(%q) got error %v, wanted %q", tt.value, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePolicy(%q) failed: %s", tt.value, err)
+			continue
+		}
+		if p != tt.want {
+			t.Errorf("ParsePolicy(%q) got %q, wanted %q", tt.value, p, tt.want)
+		}
+	}
+}
+
+func TestTrusted(t *testing.T) {
+	c := makeChecker(t, &fakeGitHub{
+		collaborators: []string{"testing/repo/collaborator"},
+		members:       []string{"testing/member"},
+	})
+
+	trustedTests := []struct {
+		repo string
+		user string
+		want bool
+	}{
+		{"testing/repo", "collaborator", true},
+		{"testing/repo", "member", true},
+		{"testing/other", "collaborator", false},
+		{"testing/repo", "octocat", false},
+		{"testing/repo", "", false},
+	}
+
+	for _, tt := range trustedTests {
+		trusted, err := c.Trusted(context.Background(), tt.repo, tt.user)
+		if err != nil {
+			t.Errorf("Trusted(%q, %q) failed: %s", tt.repo, tt.user, err)
+			continue
+		}
+		if trusted != tt.want {
+			t.Errorf("Trusted(%q, %q) got %v, wanted %v", tt.repo, tt.user, trusted, tt.want)
+		}
+	}
+}
+
+func TestTrustedWithAPIError(t *testing.T) {
+	c := makeChecker(t, &fakeGitHub{status: http.StatusForbidden})
+
+	_, err := c.Trusted(context.Background(), "testing/repo", "octocat")
+	if err == nil || !strings.Contains(err.Error(), "failed to check if octocat is a collaborator on testing/repo") {
+		t.Fatalf("Trusted() got error %v", err)
+	}
+}
+
+func TestTrustedWithInvalidRepo(t *testing.T) {
+	c := makeChecker(t, &fakeGitHub{})
+
+	_, err := c.Trusted(context.Background(), "testing", "octocat")
+	if err == nil || !strings.Contains(err.Error(), `invalid repository name "testing"`) {
+		t.Fatalf("Trusted() got error %v", err)
+	}
+}
+
+func TestTrustedIsCached(t *testing.T) {
+	f := &fakeGitHub{members: []string{"testing/member"}}
+	c := makeChecker(t, f)
+
+	for _, user := range []string{"member", "member", "octocat", "octocat"} {
+		if _, err := c.Trusted(context.Background(), "testing/repo", user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each user needs a collaborator and a member request.
+	if n := f.requestCount(); n != 4 {
+		t.Fatalf("got %d API requests, wanted 4", n)
+	}
+}
+
+func TestOKToTest(t *testing.T) {
+	before, after := testCommitted.Add(-time.Hour), testCommitted.Add(time.Hour)
+	approve := "/ok-to-test " + testSHA[:MinApprovalSHALength]
+	okTests := []struct {
+		name     string
+		comments []comment
+		want     bool
+	}{
+		{"no comments", nil, false},
+		{"trusted comment", []comment{{"MEMBER", approve, after}}, true},
+		{"command on a later line", []comment{{"OWNER", "Looks safe.\n" + approve + " please", after}}, true},
+		{"untrusted comment", []comment{{"CONTRIBUTOR", approve, after}}, false},
+		{"command not at the start", []comment{{"COLLABORATOR", "is this " + approve + "?", after}}, false},
+		{"on the second page", []comment{{"NONE", "please", after}, {"NONE", "test", after}, {"COLLABORATOR", approve, after}}, true},
+		{"comment before the commit", []comment{{"MEMBER", approve, before}}, true},
+		{"full head SHA", []comment{{"MEMBER", "/ok-to-test " + testSHA, after}}, true},
+		{"short head SHA", []comment{{"MEMBER", "/ok-to-test " + testSHA[:7], after}}, false},
+		{"other SHA", []comment{{"MEMBER", "/ok-to-test 0123456789abcdef", after}}, false},
+		{"no SHA", []comment{{"MEMBER", "/ok-to-test", after}}, false},
+		{"no SHA with other text", []comment{{"MEMBER", "/ok-to-test please", after}}, false},
+	}
+
+	for _, tt := range okTests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := makeChecker(t, &fakeGitHub{
+				comments: map[string][]comment{"testing/repo#12": tt.comments},
+				commits:  map[string]time.Time{"testing/repo@" + testSHA: testCommitted},
+			})
+
+			ok, err := c.OKToTest(context.Background(), "testing/repo", 12, testSHA)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.want {
+				t.Fatalf("OKToTest() got %v, wanted %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+// The committer date of a commit is set by its author, so a commit pushed
+// after an "/ok-to-test" comment can claim to be older than the comment.
+func TestOKToTestWithBackdatedCommit(t *testing.T) {
+	approved, backdated := testSHA, "0d3c2b5d9e0c1ef1c6e0a41ea8c7d86b3a3dc1f2"
+	c := makeChecker(t, &fakeGitHub{
+		comments: map[string][]comment{
+			"testing/repo#12": {
+				{"MEMBER", "/ok-to-test", testCommitted},
+				{"MEMBER", "/ok-to-test " + approved, testCommitted},
+			},
+		},
+		commits: map[string]time.Time{
+			"testing/repo@" + approved:  testCommitted.Add(-time.Hour),
+			"testing/repo@" + backdated: testCommitted.Add(-2 * time.Hour),
+		},
+	})
+
+	ok, err := c.OKToTest(context.Background(), "testing/repo", 12, backdated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("OKToTest() approved a backdated commit")
+	}
+}
+
+func TestOKToTestCachesApprovals(t *testing.T) {
+	f := &fakeGitHub{
+		comments: map[string][]comment{
+			"testing/repo#12": {{"MEMBER", "/ok-to-test " + testSHA, testCommitted}},
+		},
+	}
+	c := makeChecker(t, f)
+
+	for _, number := range []int{12, 12, 13, 13} {
+		if _, err := c.OKToTest(context.Background(), "testing/repo", number, testSHA); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Pull request 13 isn't approved, so it's not cached.
+	if n := f.requestCount(); n != 3 {
+		t.Fatalf("got %d API requests, wanted 3", n)
+	}
+}
+
+func TestRequireAllow(t *testing.T) {
+	policyTests := []struct {
+		policy  string
+		wantErr string
+	}{
+		{"", ""},
+		{"allow", ""},
+		{"reject", `fork policy "reject" is not supported for provider gitlab`},
+		{"ok-to-test", `fork policy "ok-to-test" is not supported for provider gitlab`},
+		{"never", `invalid Pullrequest-Fork-Policy: unknown fork policy "never"`},
+	}
+
+	for _, tt := range policyTests {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set(PolicyHeader, tt.policy)
+
+		err := RequireAllow(r, "gitlab")
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("RequireAllow() with %q failed: %s", tt.policy, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("RequireAllow() with %q got error %v, wanted %q", tt.policy, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNewClientWithToken(t *testing.T) {
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL+"/api/v3", "test-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewChecker(client).Trusted(context.Background(), "testing/repo", "octocat"); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "token test-token" {
+		t.Fatalf("got Authorization %q, wanted %q", auth, "token test-token")
+	}
+}
+
+func TestNewClientWithInvalidURL(t *testing.T) {
+	_, err := NewClient("http://[::1", "")
+	if err == nil || !strings.Contains(err.Error(), "invalid GitHub API URL") {
+		t.Fatalf("NewClient() got error %v", err)
+	}
+}
+
+const testSHA = "6a6bcddc365ca3a38c9055a603c9590a7fae7ca6"
+
+var testCommitted = time.Date(2026, time.October, 18, 10, 0, 0, 0, time.UTC)
+
+type comment struct {
+	association string
+	body        string
+	created     time.Time
+}
+
+// fakeGitHub implements the parts of the GitHub API used by the Checker.
+//
+// Collaborators are "owner/repo/user", members are "org/user", comments
+// are keyed by "owner/repo#number", and are returned two to a page, and the
+// commit times are keyed by "owner/repo@sha".
+type fakeGitHub struct {
+	collaborators []string
+	members       []string
+	comments      map[string][]comment
+	commits       map[string]time.Time
+	status        int
+	requests      int32
+}
+
+func (f *fakeGitHub) requestCount() int {
+	return int(atomic.LoadInt32(&f.requests))
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&f.requests, 1)
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v3/"), "/")
+	switch {
+	case len(parts) == 5 && parts[0] == "repos" && parts[3] == "collaborators":
+		writeBool(w, contains(f.collaborators, strings.Join([]string{parts[1], parts[2], parts[4]}, "/")))
+	case len(parts) == 4 && parts[0] == "orgs" && parts[2] == "members":
+		writeBool(w, contains(f.members, parts[1]+"/"+parts[3]))
+	case len(parts) == 6 && parts[0] == "repos" && parts[5] == "comments":
+		f.writeComments(w, r, fmt.Sprintf("%s/%s#%s", parts[1], parts[2], parts[4]))
+	case len(parts) == 5 && parts[0] == "repos" && parts[3] == "commits":
+		f.writeCommit(w, r, fmt.Sprintf("%s/%s@%s", parts[1], parts[2], parts[4]))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeGitHub) writeComments(w http.ResponseWriter, r *http.Request, key string) {
+	const perPage = 2
+	page := 1
+	fmt.Sscan(r.URL.Query().Get("page"), &page)
+	comments := f.comments[key]
+	start, end := (page-1)*perPage, page*perPage
+	if start > len(comments) {
+		start = len(comments)
+	}
+	if end >= len(comments) {
+		end = len(comments)
+	} else {
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=%d>; rel="next"`, r.Host, r.URL.Path, page+1))
+	}
+	result := []map[string]interface{}{}
+	for _, c := range comments[start:end] {
+		result = append(result, map[string]interface{}{"author_association": c.association, "body": c.body, "created_at": c.created})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func (f *fakeGitHub) writeCommit(w http.ResponseWriter, r *http.Request, key string) {
+	committed, ok := f.commits[key]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"commit": map[string]interface{}{"committer": map[string]interface{}{"date": committed}},
+	})
+}
+
+func writeBool(w http.ResponseWriter, b bool) {
+	if b {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func makeChecker(t *testing.T, f *fakeGitHub) *Checker {
+	t.Helper()
+	ts := httptest.NewServer(f)
+	t.Cleanup(ts.Close)
+	client, err := NewClient(ts.URL+"/api/v3/", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewChecker(client)
+}
@@ -0,0 +1,74 @@
+package git
+
+import "strings"
+
+// RefKind is the kind of thing that a ref points to.
+type RefKind string
+
+// These are the kinds of ref that are recognised.
+const (
+	Branch      RefKind = "branch"
+	Tag         RefKind = "tag"
+	PullRequest RefKind = "pull"
+	Note        RefKind = "note"
+	Remote      RefKind = "remote"
+	// Other is the kind for refs that are not in any of the known
+	// namespaces, including names that are not full refs, e.g. "main".
+	Other RefKind = "other"
+)
+
+// refPrefixes are the namespaces for each kind of ref, GitHub pull requests
+// are under "refs/pull/" and GitLab merge requests under
+// "refs/merge-requests/".
+var refPrefixes = []struct {
+	prefix string
+	kind   RefKind
+}{
+	{"refs/heads/", Branch},
+	{"refs/tags/", Tag},
+	{"refs/pull/", PullRequest},
+	{"refs/merge-requests/", PullRequest},
+	{"refs/notes/", Note},
+	{"refs/remotes/", Remote},
+}
+
+// Ref is a parsed ref.
+type Ref struct {
+	// Name is the ref that was parsed e.g. "refs/heads/main".
+	Name string
+	// Kind is the kind of ref e.g. Branch.
+	Kind RefKind
+	// Short is the name without the namespace prefix, e.g. "main" for
+	// "refs/heads/main", "v1.0.0" for "refs/tags/v1.0.0", or "12/head" for
+	// "refs/pull/12/head".
+	Short string
+}
+
+// ParseRef parses a ref into its kind and short name.
+//
+// Refs that are not in a known namespace have the kind Other, and the short
+// name is the ref unchanged.
+func ParseRef(s string) Ref {
+	for _, p := range refPrefixes {
+		if strings.HasPrefix(s, p.prefix) {
+			return Ref{Name: s, Kind: p.kind, Short: strings.TrimPrefix(s, p.prefix)}
+		}
+	}
+	return Ref{Name: s, Kind: Other, Short: s}
+}
+
+// BranchName strips the "refs/heads/" prefix from a branch ref, other refs
+// are returned unchanged, so that tags can be distinguished from branches.
+func BranchName(s string) string {
+	if r := ParseRef(s); r.Kind == Branch {
+		return r.Short
+	}
+	return s
+}
+
+// TagName returns the name of the tag for a "refs/tags/" ref, and false if
+// the ref is not a tag.
+func TagName(s string) (string, bool) {
+	r := ParseRef(s)
+	return r.Short, r.Kind == Tag
+}
@@ -0,0 +1,80 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRef(t *testing.T) {
+	refTests := []struct {
+		ref       string
+		wantKind  RefKind
+		wantShort string
+	}{
+		{"refs/heads/main", Branch, "main"},
+		{"refs/heads/feature/login", Branch, "feature/login"},
+		{"refs/tags/v1.0.0", Tag, "v1.0.0"},
+		{"refs/pull/12/head", PullRequest, "12/head"},
+		{"refs/merge-requests/12/head", PullRequest, "12/head"},
+		{"refs/notes/commits", Note, "commits"},
+		{"refs/remotes/origin/main", Remote, "origin/main"},
+		{"refs/other/main", Other, "refs/other/main"},
+		{"main", Other, "main"},
+		{"", Other, ""},
+	}
+
+	for _, tt := range refTests {
+		r := ParseRef(tt.ref)
+		if r.Name != tt.ref || r.Kind != tt.wantKind || r.Short != tt.wantShort {
+			t.Errorf("ParseRef(%q) got %#v, wanted kind %q and short name %q", tt.ref, r, tt.wantKind, tt.wantShort)
+		}
+	}
+}
+
+func TestBranchName(t *testing.T) {
+	branchTests := []struct {
+		ref  string
+		want string
+	}{
+		{"refs/heads/master", "master"},
+		{"refs/heads/release/v1", "release/v1"},
+		{"refs/tags/v1.0.0", "refs/tags/v1.0.0"},
+		{"master", "master"},
+		{"", ""},
+	}
+
+	for _, tt := range branchTests {
+		if got := BranchName(tt.ref); got != tt.want {
+			t.Errorf("BranchName(%q) got %q, wanted %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestTagName(t *testing.T) {
+	if tag, ok := TagName("refs/tags/v1.0.0"); !ok || tag != "v1.0.0" {
+		t.Errorf("TagName() got %q, %v, wanted %q, true", tag, ok, "v1.0.0")
+	}
+	if _, ok := TagName("refs/heads/v1.0.0"); ok {
+		t.Error("TagName() with a branch got true, wanted false")
+	}
+}
+
+func FuzzParseRef(f *testing.F) {
+	for _, s := range []string{"refs/heads/main", "refs/tags/v1.0.0", "refs/pull/12/head", "refs/", "main", ""} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		r := ParseRef(s)
+		if r.Name != s {
+			t.Fatalf("ParseRef(%q) got name %q", s, r.Name)
+		}
+		if !strings.HasSuffix(s, r.Short) {
+			t.Fatalf("ParseRef(%q) got short name %q, not a suffix of the ref", s, r.Short)
+		}
+		if r.Kind == Other && r.Short != s {
+			t.Fatalf("ParseRef(%q) got short name %q for an unknown ref", s, r.Short)
+		}
+		BranchName(s)
+		TagName(s)
+	})
+}
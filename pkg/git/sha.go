@@ -0,0 +1,65 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// SHA1Length is the length of a hex SHA-1 object name.
+	SHA1Length = 40
+	// SHA256Length is the length of a hex SHA-256 object name.
+	SHA256Length = 64
+
+	// DefaultAbbrevLength is the default length that SHAs are shortened to.
+	DefaultAbbrevLength = 6
+	// MinAbbrevLength is the shortest length that SHAs can be shortened to,
+	// this is the same as git.
+	MinAbbrevLength = 4
+)
+
+// CheckAbbrevLength returns an error if SHAs can't be shortened to n
+// characters with AbbreviateSHA.
+func CheckAbbrevLength(n int) error {
+	if n < MinAbbrevLength || n > SHA256Length {
+		return fmt.Errorf("invalid SHA abbreviation length %d, must be between %d and %d", n, MinAbbrevLength, SHA256Length)
+	}
+	return nil
+}
+
+// ShortenSHA trims a SHA to the DefaultAbbrevLength, SHAs that are already
+// shorter are returned unchanged.
+func ShortenSHA(s string) string {
+	return AbbreviateSHA(s, DefaultAbbrevLength)
+}
+
+// AbbreviateSHA trims a SHA to n characters, SHAs that are already shorter
+// are returned unchanged, as are all SHAs if n is not positive.
+func AbbreviateSHA(s string, n int) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// IsSHA returns true if the string is a full SHA-1 or SHA-256 object name,
+// in lowercase hex.
+func IsSHA(s string) bool {
+	return (len(s) == SHA1Length || len(s) == SHA256Length) && isHex(s)
+}
+
+// IsZeroSHA returns true if the SHA is all zeros, providers use this as the
+// "before" SHA when a ref is created, and the "after" SHA when a ref is
+// deleted.
+func IsZeroSHA(s string) bool {
+	return s != "" && strings.Trim(s, "0") == ""
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
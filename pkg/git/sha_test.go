@@ -0,0 +1,128 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	testSHA1   = "6a6bcddc365ca3a38c9055a603c9590a7fae7ca6"
+	testSHA256 = "6a6bcddc365ca3a38c9055a603c9590a7fae7ca66a6bcddc365ca3a38c9055a6"
+)
+
+func TestShortenSHA(t *testing.T) {
+	shaTests := []struct {
+		sha  string
+		want string
+	}{
+		{testSHA1, "6a6bcd"},
+		{testSHA256, "6a6bcd"},
+		{"6a6b", "6a6b"},
+		{"", ""},
+	}
+
+	for _, tt := range shaTests {
+		if s := ShortenSHA(tt.sha); s != tt.want {
+			t.Errorf("ShortenSHA(%q) got %s, wanted %s", tt.sha, s, tt.want)
+		}
+	}
+}
+
+func TestCheckAbbrevLength(t *testing.T) {
+	for _, n := range []int{MinAbbrevLength, DefaultAbbrevLength, SHA1Length, SHA256Length} {
+		if err := CheckAbbrevLength(n); err != nil {
+			t.Errorf("CheckAbbrevLength(%d) failed: %s", n, err)
+		}
+	}
+	for _, n := range []int{0, 3, 65} {
+		if err := CheckAbbrevLength(n); err == nil {
+			t.Errorf("CheckAbbrevLength(%d) expected an error", n)
+		}
+	}
+}
+
+func TestAbbreviateSHA(t *testing.T) {
+	abbrevTests := []struct {
+		sha  string
+		n    int
+		want string
+	}{
+		{testSHA1, 7, "6a6bcdd"},
+		{testSHA1, 40, testSHA1},
+		{testSHA1, 100, testSHA1},
+		{testSHA1, 0, testSHA1},
+		{testSHA1, -1, testSHA1},
+		{"", 7, ""},
+	}
+
+	for _, tt := range abbrevTests {
+		if s := AbbreviateSHA(tt.sha, tt.n); s != tt.want {
+			t.Errorf("AbbreviateSHA(%q, %d) got %s, wanted %s", tt.sha, tt.n, s, tt.want)
+		}
+	}
+}
+
+func TestIsSHA(t *testing.T) {
+	shaTests := []struct {
+		sha  string
+		want bool
+	}{
+		{testSHA1, true},
+		{testSHA256, true},
+		{"6a6bcd", false},
+		{"6A6BCDDC365CA3A38C9055A603C9590A7FAE7CA6", false},
+		{"6a6bcddc365ca3a38c9055a603c9590a7fae7cag", false},
+		{"", false},
+	}
+
+	for _, tt := range shaTests {
+		if got := IsSHA(tt.sha); got != tt.want {
+			t.Errorf("IsSHA(%q) got %v, wanted %v", tt.sha, got, tt.want)
+		}
+	}
+}
+
+func TestIsZeroSHA(t *testing.T) {
+	shaTests := []struct {
+		sha  string
+		want bool
+	}{
+		{"0000000000000000000000000000000000000000", true},
+		{testSHA1, false},
+		{"", false},
+	}
+
+	for _, tt := range shaTests {
+		if got := IsZeroSHA(tt.sha); got != tt.want {
+			t.Errorf("IsZeroSHA(%q) got %v, wanted %v", tt.sha, got, tt.want)
+		}
+	}
+}
+
+func FuzzAbbreviateSHA(f *testing.F) {
+	f.Add(testSHA1, 6)
+	f.Add(testSHA256, 12)
+	f.Add("", 0)
+	f.Add("abc", -1)
+	f.Fuzz(func(t *testing.T, s string, n int) {
+		got := AbbreviateSHA(s, n)
+		if !strings.HasPrefix(s, got) {
+			t.Fatalf("AbbreviateSHA(%q, %d) got %q, not a prefix of the SHA", s, n, got)
+		}
+		want := len(s)
+		if n > 0 && n < want {
+			want = n
+		}
+		if len(got) != want {
+			t.Fatalf("AbbreviateSHA(%q, %d) got %q, wanted %d characters", s, n, got, want)
+		}
+		if IsSHA(s) {
+			if len(s) != SHA1Length && len(s) != SHA256Length {
+				t.Fatalf("IsSHA(%q) got true for a SHA of length %d", s, len(s))
+			}
+			if strings.Trim(s, "0123456789abcdef") != "" {
+				t.Fatalf("IsSHA(%q) got true for a SHA that isn't lowercase hex", s)
+			}
+		}
+	})
+}
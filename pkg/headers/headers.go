@@ -0,0 +1,20 @@
+// Package headers parses the values of the headers that configure the
+// interceptor.
+package headers
+
+import "strings"
+
+// SplitList splits a comma-separated list, ignoring whitespace around the
+// values, and empty values.
+//
+// Lists of patterns should be parsed with pattern.ParseList, as regular
+// expressions can contain commas.
+func SplitList(s string) []string {
+	values := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,23 @@
+package headers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitList(t *testing.T) {
+	splitTests := []struct {
+		s    string
+		want []string
+	}{
+		{"", []string{}},
+		{"opened", []string{"opened"}},
+		{"opened, reopened,", []string{"opened", "reopened"}},
+	}
+
+	for _, tt := range splitTests {
+		if v := SplitList(tt.s); !reflect.DeepEqual(v, tt.want) {
+			t.Errorf("SplitList(%q) got %#v, wanted %#v", tt.s, v, tt.want)
+		}
+	}
+}
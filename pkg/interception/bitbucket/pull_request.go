@@ -0,0 +1,124 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/forks"
+	"github.com/bigkevmcd/interceptor/pkg/interception/pullrequest"
+	"github.com/bigkevmcd/interceptor/pkg/logging"
+	"github.com/bigkevmcd/interceptor/pkg/skip"
+)
+
+// InterceptPullRequest is an Interceptor that checks that the Bitbucket Cloud
+// "pullrequest:*" or Bitbucket Server "pr:*" hook body matches the requested
+// fields.
+//
+// It recognises the same request headers as the GitHub pull_request handler:
+//    X-Event-Key - this is provided by Bitbucket in its hook-mechanism
+//    Pullrequest-Action - this is configured on the trigger interceptor, and
+//    is matched against the part of the event key after the prefix, e.g.
+//    "created" for "pullrequest:created" or "opened" for "pr:opened".
+//    Pullrequest-Repo - this is the full name of the destination repository.
+//    Pullrequest-Base-Ref - patterns for the destination branch.
+//    Pullrequest-Head-Ref - patterns for the source branch.
+//    Pullrequest-Include-Drafts - draft pull requests only match if this is
+//    "true".
+//
+// Bitbucket pull requests have no labels, so hooks are rejected with an
+// error if the Pullrequest-Labels or Pullrequest-Exclude-Labels headers are
+// set, and the authors of pull requests from forks can't be checked, so they
+// are also rejected with an error if a Pullrequest-Fork-Policy other than
+// "allow" is configured, see forks.RequireAllow.
+//
+// If the request matches the configuration, the hook is allowed with the same
+// "intercepted" values as the GitHub pull_request handler.
+func InterceptPullRequest(r *http.Request, body []byte) (*decision.Decision, error) {
+	eventKey := r.Header.Get(EventHeader)
+	action := actionFromEventKey(eventKey)
+	if action == "" {
+		logging.Decision(r.Context(), decision.EventMismatch)
+		return decision.Denied(decision.EventMismatch), nil
+	}
+
+	if err := checkUnsupportedHeaders(r); err != nil {
+		return nil, err
+	}
+	if err := forks.RequireAllow(r, "bitbucket"); err != nil {
+		return nil, err
+	}
+
+	e := &event.Event{Type: event.PullRequest, Action: action}
+	if IsServerEvent(eventKey) {
+		var hook ServerPullRequestHook
+		if err := json.Unmarshal(body, &hook); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal request body: %w", err)
+		}
+		pr := hook.PullRequest
+		e.Repo = pr.ToRef.Repository.FullName()
+		e.Number = pr.ID
+		e.Title = pr.Title
+		e.Description = pr.Description
+		e.BaseRef = pr.ToRef.DisplayID
+		e.HeadRef = pr.FromRef.DisplayID
+		e.HeadRepo = pr.FromRef.Repository.FullName()
+		e.Draft = pr.Draft
+		e.SHA = pr.FromRef.LatestCommit
+	} else {
+		var hook CloudPullRequestHook
+		if err := json.Unmarshal(body, &hook); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal request body: %w", err)
+		}
+		pr := hook.PullRequest
+		e.Repo = hook.Repository.FullName
+		e.Number = pr.ID
+		e.Title = pr.Title
+		e.Description = pr.Description
+		e.BaseRef = pr.Destination.Branch.Name
+		e.HeadRef = pr.Source.Branch.Name
+		e.HeadRepo = pr.Source.Repository.FullName
+		e.Draft = pr.Draft
+		e.SHA = pr.Source.Commit.Hash
+	}
+
+	opts := skip.FromRequest(r)
+	match, reason, err := pullrequest.MatchEventWithSkipOptions(r, e, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error matching pull request: %w", err)
+	}
+	logging.Decision(r.Context(), reason, opts.DecisionFields(e, reason,
+		zap.String("repo", e.Repo), zap.String("action", action))...)
+	if !match {
+		return decision.Denied(reason), nil
+	}
+
+	return decision.AllowedEvent(e, pullrequest.EventValues(e)), nil
+}
+
+// unsupportedHeaders are the pull request headers that can't be matched
+// against Bitbucket pull requests.
+var unsupportedHeaders = []string{"Pullrequest-Labels", "Pullrequest-Exclude-Labels"}
+
+func checkUnsupportedHeaders(r *http.Request) error {
+	for _, h := range unsupportedHeaders {
+		if r.Header.Get(h) != "" {
+			return fmt.Errorf("%s is not supported for Bitbucket pull requests", h)
+		}
+	}
+	return nil
+}
+
+// PullRequestHandler is an InterceptionFunc that returns the body with the intercepted
+// values from InterceptPullRequest, or nil if the pull request doesn't match.
+func PullRequestHandler(r *http.Request, body []byte) ([]byte, error) {
+	d, err := InterceptPullRequest(r, body)
+	if err != nil {
+		return nil, err
+	}
+	return d.Apply(body)
+}
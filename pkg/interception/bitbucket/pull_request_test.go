@@ -0,0 +1,214 @@
+package bitbucket
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/sjson"
+
+	"github.com/bigkevmcd/interceptor/pkg/interception/internal/hooktest"
+)
+
+const (
+	testCloudPullRequest = `{
+  "pullrequest": {
+    "id": 1,
+    "title": "Testing",
+    "source": {"branch": {"name": "my-branch"}, "commit": {"hash": "abc123456789"}, "repository": {"full_name": "testing/testing"}},
+    "destination": {"branch": {"name": "master"}, "commit": {"hash": "def123456789"}, "repository": {"full_name": "testing/testing"}}
+  },
+  "repository": {"full_name": "testing/testing"}
+}`
+
+	testServerPullRequest = `{
+  "eventKey": "pr:opened",
+  "pullRequest": {
+    "id": 1,
+    "title": "Testing",
+    "fromRef": {"id": "refs/heads/my-branch", "displayId": "my-branch", "latestCommit": "abc123456789", "repository": {"slug": "testing", "project": {"key": "PROJ"}}},
+    "toRef": {"id": "refs/heads/master", "displayId": "master", "latestCommit": "def123456789", "repository": {"slug": "testing", "project": {"key": "PROJ"}}}
+  }
+}`
+)
+
+func TestPullRequestHandler(t *testing.T) {
+	prTests := []struct {
+		name      string
+		eventKey  string
+		body      string
+		repo      string
+		action    string
+		wantMatch bool
+	}{
+		{"cloud created", "pullrequest:created", testCloudPullRequest, "testing/testing", "created,updated", true},
+		{"cloud updated", "pullrequest:updated", testCloudPullRequest, "testing/testing", "created,updated", true},
+		{"cloud merged", "pullrequest:fulfilled", testCloudPullRequest, "testing/testing", "created,updated", false},
+		{"cloud other repo", "pullrequest:created", testCloudPullRequest, "testing/other", "created", false},
+		{"server opened", "pr:opened", testServerPullRequest, "PROJ/testing", "opened,from_ref_updated", true},
+		{"server declined", "pr:declined", testServerPullRequest, "PROJ/testing", "opened", false},
+		{"push event", CloudPushEvent, testCloudPullRequest, "testing/testing", "created", false},
+	}
+
+	for _, tt := range prTests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(tt.body)
+			r := hooktest.MakeRequest(body, EventHeader, tt.eventKey, map[string]string{
+				"Pullrequest-Repo":   tt.repo,
+				"Pullrequest-Action": tt.action,
+			})
+
+			newBody, err := PullRequestHandler(r, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.wantMatch {
+				if newBody != nil {
+					t.Fatalf("PullRequestHandler() got %s, wanted nil", newBody)
+				}
+				return
+			}
+			hooktest.AssertIntercepted(t, newBody, map[string]string{
+				"short_sha":          "abc123",
+				"fullname":           tt.repo,
+				"number":             "1",
+				"base_ref":           "master",
+				"head_ref":           "my-branch",
+				"head_repo_fullname": tt.repo,
+				"is_fork":            "false",
+			})
+		})
+	}
+}
+
+func TestPullRequestHandlerWithRefs(t *testing.T) {
+	refTests := []struct {
+		name      string
+		eventKey  string
+		body      string
+		baseRef   string
+		headRef   string
+		wantMatch bool
+	}{
+		{"cloud matching refs", "pullrequest:created", testCloudPullRequest, "master", "glob:my-*", true},
+		{"cloud other base ref", "pullrequest:created", testCloudPullRequest, "develop", "", false},
+		{"server matching refs", "pr:opened", testServerPullRequest, "master", "my-branch", true},
+		{"server other head ref", "pr:opened", testServerPullRequest, "", "glob:fix/*", false},
+	}
+
+	for _, tt := range refTests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(tt.body)
+			r := hooktest.MakeRequest(body, EventHeader, tt.eventKey, map[string]string{
+				"Pullrequest-Repo":     "testing/testing,PROJ/testing",
+				"Pullrequest-Action":   "created,opened",
+				"Pullrequest-Base-Ref": tt.baseRef,
+				"Pullrequest-Head-Ref": tt.headRef,
+			})
+
+			newBody, err := PullRequestHandler(r, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match := newBody != nil; match != tt.wantMatch {
+				t.Fatalf("PullRequestHandler() got match %v, wanted %v", match, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestPullRequestHandlerWithDrafts(t *testing.T) {
+	body, err := sjson.SetBytes([]byte(testCloudPullRequest), "pullrequest.draft", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	draftTests := []struct {
+		includeDrafts string
+		wantMatch     bool
+	}{
+		{"", false},
+		{"true", true},
+	}
+
+	for _, tt := range draftTests {
+		r := hooktest.MakeRequest(body, EventHeader, "pullrequest:created", map[string]string{
+			"Pullrequest-Repo":           "testing/testing",
+			"Pullrequest-Action":         "created",
+			"Pullrequest-Include-Drafts": tt.includeDrafts,
+		})
+
+		newBody, err := PullRequestHandler(r, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match := newBody != nil; match != tt.wantMatch {
+			t.Errorf("PullRequestHandler() with include drafts %q got match %v, wanted %v", tt.includeDrafts, match, tt.wantMatch)
+		}
+	}
+}
+
+func TestPullRequestHandlerWithLabels(t *testing.T) {
+	for _, h := range []string{"Pullrequest-Labels", "Pullrequest-Exclude-Labels"} {
+		body := []byte(testCloudPullRequest)
+		r := hooktest.MakeRequest(body, EventHeader, "pullrequest:created", map[string]string{
+			"Pullrequest-Repo":   "testing/testing",
+			"Pullrequest-Action": "created",
+			h:                    "run-e2e",
+		})
+
+		_, err := PullRequestHandler(r, body)
+		if err == nil || !strings.Contains(err.Error(), h+" is not supported for Bitbucket pull requests") {
+			t.Errorf("PullRequestHandler() with %s got error %v", h, err)
+		}
+	}
+}
+
+func TestPullRequestHandlerWithForkPolicy(t *testing.T) {
+	policyTests := []struct {
+		policy  string
+		wantErr string
+	}{
+		{"allow", ""},
+		{"reject", `fork policy "reject" is not supported for provider bitbucket`},
+		{"ok-to-test", `fork policy "ok-to-test" is not supported for provider bitbucket`},
+	}
+
+	for _, tt := range policyTests {
+		t.Run(tt.policy, func(t *testing.T) {
+			body := []byte(testCloudPullRequest)
+			r := hooktest.MakeRequest(body, EventHeader, "pullrequest:created", map[string]string{
+				"Pullrequest-Repo":        "testing/testing",
+				"Pullrequest-Action":      "created",
+				"Pullrequest-Fork-Policy": tt.policy,
+			})
+
+			newBody, err := PullRequestHandler(r, body)
+			if tt.wantErr == "" {
+				if err != nil || newBody == nil {
+					t.Fatalf("PullRequestHandler() got %s, %v, wanted a match", newBody, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("PullRequestHandler() got error %v, wanted %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestActionFromEventKey(t *testing.T) {
+	keyTests := []struct {
+		key  string
+		want string
+	}{
+		{"pullrequest:created", "created"},
+		{"pr:opened", "opened"},
+		{"pr:reviewer:approved", "reviewer:approved"},
+		{"repo:push", ""},
+	}
+
+	for _, tt := range keyTests {
+		if a := actionFromEventKey(tt.key); a != tt.want {
+			t.Errorf("actionFromEventKey(%q) got %q, wanted %q", tt.key, a, tt.want)
+		}
+	}
+}
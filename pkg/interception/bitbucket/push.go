@@ -0,0 +1,163 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/git"
+	"github.com/bigkevmcd/interceptor/pkg/interception/push"
+	"github.com/bigkevmcd/interceptor/pkg/logging"
+	"github.com/bigkevmcd/interceptor/pkg/naming"
+	"github.com/bigkevmcd/interceptor/pkg/skip"
+)
+
+// InterceptPush is an Interceptor that checks that the Bitbucket Cloud
+// "repo:push" or Bitbucket Server "repo:refs_changed" hook body matches the
+// requested fields.
+//
+// It recognises the same request headers as the GitHub push handler:
+//    X-Event-Key - this is provided by Bitbucket in its hook-mechanism
+//    Push-Ref - this is configured on the trigger interceptor
+//    PushExclude-Ref - this is configured on the trigger interceptor
+//    Push-Repo - this is the full name of the repository, e.g.
+//    "workspace/repo" for Bitbucket Cloud, or "PROJECT/repo" for Bitbucket
+//    Server.
+//
+// Bitbucket Cloud pushes with a skip marker like "[skip ci]" in the head
+// commit message are rejected, see skip.FromRequest, Bitbucket Server hooks
+// have no commit messages, so these are never skipped.
+//
+// A push can change several refs, the hook matches if any of the changed
+// refs match, deleted refs are ignored unless Push-On-Delete is "true".
+//
+// If the request matches the configuration, the hook is allowed with the same
+// "intercepted" values as the GitHub push handler, for the first matching
+// change, otherwise it's rejected with the reason for the last change.
+func InterceptPush(r *http.Request, body []byte) (*decision.Decision, error) {
+	eventKey := r.Header.Get(EventHeader)
+	var changes []*event.Event
+	var err error
+	switch eventKey {
+	case CloudPushEvent:
+		changes, err = parseCloudPush(body)
+	case ServerPushEvent:
+		changes, err = parseServerPush(body)
+	default:
+		logging.Decision(r.Context(), decision.EventMismatch)
+		return decision.Denied(decision.EventMismatch), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// If the push didn't change any refs, there are no changes to match.
+	reason := decision.RefNotIncluded
+	opts := skip.FromRequest(r)
+	for _, e := range changes {
+		var match bool
+		match, reason, err = push.MatchEventWithSkipOptions(r, e, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error matching push: %w", err)
+		}
+		logging.Decision(r.Context(), reason,
+			opts.DecisionFields(e, reason, zap.String("repo", e.Repo), zap.String("ref", e.Ref))...)
+		if !match {
+			continue
+		}
+		intercepted := map[string]interface{}{
+			"ref":       e.Ref,
+			"short_sha": git.ShortenSHA(e.SHA),
+			"fullname":  e.Repo,
+		}
+		push.AddTagValues(intercepted, e.Ref)
+		push.AddRefChangeValues(intercepted, e)
+		naming.AddValues(intercepted, e)
+		return decision.AllowedEvent(e, intercepted), nil
+	}
+	return decision.Denied(reason), nil
+}
+
+// PushHandler is an InterceptionFunc that returns the body with the intercepted
+// values from InterceptPush, or nil if the push doesn't match.
+func PushHandler(r *http.Request, body []byte) ([]byte, error) {
+	d, err := InterceptPush(r, body)
+	if err != nil {
+		return nil, err
+	}
+	return d.Apply(body)
+}
+
+// parseCloudPush returns a normalised event for each of the refs changed in
+// a Bitbucket Cloud push.
+func parseCloudPush(body []byte) ([]*event.Event, error) {
+	var hook CloudPushHook
+	if err := json.Unmarshal(body, &hook); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request body: %w", err)
+	}
+	changes := []*event.Event{}
+	for _, c := range hook.Push.Changes {
+		e := &event.Event{
+			Type:    event.Push,
+			Repo:    hook.Repository.FullName,
+			Created: c.Created,
+			Deleted: c.Closed,
+			Forced:  c.Forced,
+		}
+		ref := c.New
+		if ref == nil {
+			ref = c.Old
+		}
+		if ref == nil {
+			continue
+		}
+		e.Ref = ref.Name
+		if ref.Type == "tag" {
+			e.Ref = "refs/tags/" + e.Ref
+		}
+		if c.Old != nil {
+			e.Before = c.Old.Target.Hash
+		}
+		if c.New != nil {
+			e.After = c.New.Target.Hash
+			e.SHA = e.After
+			e.HeadCommitMessage = c.New.Target.Message
+		}
+		for _, commit := range c.Commits {
+			e.CommitMessages = append(e.CommitMessages, commit.Message)
+		}
+		changes = append(changes, e)
+	}
+	return changes, nil
+}
+
+// parseServerPush returns a normalised event for each of the refs changed in
+// a Bitbucket Server push.
+//
+// Bitbucket Server doesn't indicate whether a push was forced, so these are
+// never treated as force pushes, and doesn't include the commit messages, so
+// these are never skipped.
+func parseServerPush(body []byte) ([]*event.Event, error) {
+	var hook ServerPushHook
+	if err := json.Unmarshal(body, &hook); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request body: %w", err)
+	}
+	changes := []*event.Event{}
+	for _, c := range hook.Changes {
+		changes = append(changes, &event.Event{
+			Type:    event.Push,
+			Repo:    hook.Repository.FullName(),
+			Ref:     git.BranchName(c.RefID),
+			SHA:     c.ToHash,
+			Before:  c.FromHash,
+			After:   c.ToHash,
+			Created: c.Type == "ADD",
+			Deleted: c.Type == "DELETE",
+		})
+	}
+	return changes, nil
+}
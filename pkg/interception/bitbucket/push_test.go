@@ -0,0 +1,235 @@
+package bitbucket
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tidwall/sjson"
+
+	"github.com/bigkevmcd/interceptor/pkg/interception/internal/hooktest"
+)
+
+const (
+	testCloudPush = `{
+  "push": {
+    "changes": [
+      {"new": null, "old": {"type": "branch", "name": "old-branch"}, "closed": true},
+      {"new": {"type": "branch", "name": "master", "target": {"hash": "abc123456789"}}, "created": false}
+    ]
+  },
+  "repository": {"full_name": "testing/testing"}
+}`
+
+	testCloudTagPush = `{
+  "push": {
+    "changes": [
+      {"new": {"type": "tag", "name": "v1.0.0", "target": {"hash": "def123456789"}}, "created": true}
+    ]
+  },
+  "repository": {"full_name": "testing/testing"}
+}`
+
+	testServerPush = `{
+  "eventKey": "repo:refs_changed",
+  "repository": {"slug": "testing", "project": {"key": "PROJ"}},
+  "changes": [
+    {"ref": {"id": "refs/heads/master", "displayId": "master", "type": "BRANCH"}, "refId": "refs/heads/master", "fromHash": "000000000000", "toHash": "abc123456789", "type": "UPDATE"}
+  ]
+}`
+)
+
+func TestPushHandlerWithSuccess(t *testing.T) {
+	pushTests := []struct {
+		name      string
+		eventKey  string
+		body      string
+		repo      string
+		ref       string
+		wantRef   string
+		wantSHA   string
+		wantMatch bool
+	}{
+		{"cloud branch", CloudPushEvent, testCloudPush, "testing/testing", "master", "master", "abc123", true},
+		{"cloud all branches", CloudPushEvent, testCloudPush, "testing/testing", "", "master", "abc123", true},
+		{"cloud deleted branch", CloudPushEvent, testCloudPush, "testing/testing", "old-branch", "", "", false},
+		{"cloud other repo", CloudPushEvent, testCloudPush, "testing/other", "master", "", "", false},
+		{"cloud tag", CloudPushEvent, testCloudTagPush, "testing/testing", "refs/tags/v1.0.0", "refs/tags/v1.0.0", "def123", true},
+		{"server branch", ServerPushEvent, testServerPush, "PROJ/testing", "master", "master", "abc123", true},
+		{"server other branch", ServerPushEvent, testServerPush, "PROJ/testing", "my-branch", "", "", false},
+	}
+
+	for _, tt := range pushTests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(tt.body)
+			headers := map[string]string{"Push-Repo": tt.repo}
+			if tt.ref != "" {
+				headers["Push-Ref"] = tt.ref
+			}
+			r := hooktest.MakeRequest(body, EventHeader, tt.eventKey, headers)
+
+			newBody, err := PushHandler(r, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.wantMatch {
+				if newBody != nil {
+					t.Fatalf("PushHandler() got %s, wanted nil", newBody)
+				}
+				return
+			}
+			hooktest.AssertIntercepted(t, newBody, map[string]string{
+				"ref":       tt.wantRef,
+				"short_sha": tt.wantSHA,
+				"fullname":  tt.repo,
+			})
+			returnBody, err := sjson.DeleteBytes(newBody, "intercepted")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(returnBody, body) {
+				t.Fatalf("handler got incorrect body: got %s, wanted %s", newBody, body)
+			}
+		})
+	}
+}
+
+func TestPushHandlerWithDeletedRefs(t *testing.T) {
+	testServerDelete := `{
+  "eventKey": "repo:refs_changed",
+  "repository": {"slug": "testing", "project": {"key": "PROJ"}},
+  "changes": [
+    {"ref": {"id": "refs/heads/old-branch", "displayId": "old-branch", "type": "BRANCH"}, "refId": "refs/heads/old-branch", "fromHash": "abc123456789", "toHash": "0000000000000000000000000000000000000000", "type": "DELETE"}
+  ]
+}`
+	deleteTests := []struct {
+		name     string
+		eventKey string
+		body     string
+		repo     string
+	}{
+		{"cloud", CloudPushEvent, testCloudPush, "testing/testing"},
+		{"server", ServerPushEvent, testServerDelete, "PROJ/testing"},
+	}
+
+	for _, tt := range deleteTests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(tt.body)
+			r := hooktest.MakeRequest(body, EventHeader, tt.eventKey, map[string]string{
+				"Push-Repo":      tt.repo,
+				"Push-Ref":       "old-branch",
+				"Push-On-Delete": "true",
+			})
+
+			newBody, err := PushHandler(r, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hooktest.AssertIntercepted(t, newBody, map[string]string{
+				"ref":     "old-branch",
+				"deleted": "true",
+				"created": "false",
+			})
+		})
+	}
+}
+
+func TestPushHandlerWithSkipMarkers(t *testing.T) {
+	testCloudSkipPush := `{
+  "push": {
+    "changes": [
+      {
+        "new": {"type": "branch", "name": "master", "target": {"hash": "abc123456789", "message": "Update the docs [skip ci]"}},
+        "commits": [
+          {"hash": "abc123456789", "message": "Update the docs [skip ci]"},
+          {"hash": "def123456789", "message": "Fix the tests [no ci]"}
+        ]
+      }
+    ]
+  },
+  "repository": {"full_name": "testing/testing"}
+}`
+	skipTests := []struct {
+		name      string
+		eventKey  string
+		body      string
+		headers   map[string]string
+		wantMatch bool
+	}{
+		{"cloud head commit", CloudPushEvent, testCloudSkipPush, map[string]string{"Push-Repo": "testing/testing"}, false},
+		{"cloud all commits", CloudPushEvent, testCloudSkipPush, map[string]string{
+			"Push-Repo":                    "testing/testing",
+			"Interceptor-Skip-Markers":     "[no ci]",
+			"Interceptor-Skip-All-Commits": "true",
+		}, false},
+		{"cloud other markers", CloudPushEvent, testCloudSkipPush, map[string]string{
+			"Push-Repo":                "testing/testing",
+			"Interceptor-Skip-Markers": "[no ci]",
+		}, true},
+		{"cloud disabled", CloudPushEvent, testCloudSkipPush, map[string]string{
+			"Push-Repo":                 "testing/testing",
+			"Interceptor-Skip-Disabled": "true",
+		}, true},
+		// Bitbucket Server hooks have no commit messages, so they're never
+		// skipped.
+		{"server", ServerPushEvent, testServerPush, map[string]string{
+			"Push-Repo":                    "PROJ/testing",
+			"Interceptor-Skip-All-Commits": "true",
+		}, true},
+	}
+
+	for _, tt := range skipTests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(tt.body)
+			r := hooktest.MakeRequest(body, EventHeader, tt.eventKey, tt.headers)
+
+			newBody, err := PushHandler(r, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match := newBody != nil; match != tt.wantMatch {
+				t.Fatalf("PushHandler() got match %v, wanted %v", match, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestParseCloudPushCommitMessages(t *testing.T) {
+	body := `{
+  "push": {
+    "changes": [
+      {
+        "new": {"type": "branch", "name": "master", "target": {"hash": "abc123456789", "message": "Second commit"}},
+        "commits": [{"hash": "abc123456789", "message": "Second commit"}, {"hash": "def123456789", "message": "First commit"}]
+      }
+    ]
+  },
+  "repository": {"full_name": "testing/testing"}
+}`
+
+	changes, err := parseCloudPush([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := len(changes); l != 1 {
+		t.Fatalf("got %d changes, wanted 1", l)
+	}
+	if m := changes[0].HeadCommitMessage; m != "Second commit" {
+		t.Errorf("got HeadCommitMessage %q, wanted %q", m, "Second commit")
+	}
+	want := []string{"Second commit", "First commit"}
+	if m := changes[0].CommitMessages; !reflect.DeepEqual(m, want) {
+		t.Errorf("got CommitMessages %#v, wanted %#v", m, want)
+	}
+}
+
+func TestPushHandlerWithInvalidJSON(t *testing.T) {
+	for _, k := range []string{CloudPushEvent, ServerPushEvent} {
+		body := []byte(`{test`)
+		r := hooktest.MakeRequest(body, EventHeader, k, map[string]string{})
+
+		_, err := PushHandler(r, body)
+		if err == nil {
+			t.Errorf("%s: expected json parsing error, got nil", k)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package bitbucket
+
+import "strings"
+
+// These are the subset of the Bitbucket Cloud and Bitbucket Server hook
+// payloads that are needed to match hooks.
+//
+// See https://support.atlassian.com/bitbucket-cloud/docs/event-payloads/ and
+// https://confluence.atlassian.com/bitbucketserver/event-payload-938025882.html
+
+const (
+	// EventHeader is the header Bitbucket uses to identify the hook event.
+	EventHeader = "X-Event-Key"
+	// SignatureHeader is the header Bitbucket sends the "sha256=" HMAC
+	// signature of the body in, this has the same name as GitHub's legacy
+	// SHA-1 signature header.
+	SignatureHeader = "X-Hub-Signature"
+
+	// CloudPushEvent is the Bitbucket Cloud event key for pushes.
+	CloudPushEvent = "repo:push"
+	// ServerPushEvent is the Bitbucket Server event key for pushes.
+	ServerPushEvent = "repo:refs_changed"
+
+	cloudPullRequestPrefix  = "pullrequest:"
+	serverPullRequestPrefix = "pr:"
+)
+
+// CloudPullRequestEvents are the Bitbucket Cloud pull request event keys.
+var CloudPullRequestEvents = []string{
+	"pullrequest:created",
+	"pullrequest:updated",
+	"pullrequest:approved",
+	"pullrequest:unapproved",
+	"pullrequest:fulfilled",
+	"pullrequest:rejected",
+}
+
+// ServerPullRequestEvents are the Bitbucket Server pull request event keys.
+var ServerPullRequestEvents = []string{
+	"pr:opened",
+	"pr:modified",
+	"pr:from_ref_updated",
+	"pr:reviewer:approved",
+	"pr:reviewer:unapproved",
+	"pr:merged",
+	"pr:declined",
+	"pr:deleted",
+}
+
+// IsServerEvent returns true if the event key is a Bitbucket Server event.
+func IsServerEvent(eventKey string) bool {
+	return eventKey == ServerPushEvent || strings.HasPrefix(eventKey, serverPullRequestPrefix)
+}
+
+// actionFromEventKey extracts the action from a pull request event key, e.g.
+// "pullrequest:created" is "created" and "pr:reviewer:approved" is
+// "reviewer:approved".
+func actionFromEventKey(eventKey string) string {
+	for _, prefix := range []string{cloudPullRequestPrefix, serverPullRequestPrefix} {
+		if strings.HasPrefix(eventKey, prefix) {
+			return strings.TrimPrefix(eventKey, prefix)
+		}
+	}
+	return ""
+}
+
+// CloudRepository is a Bitbucket Cloud repository.
+type CloudRepository struct {
+	FullName string `json:"full_name"`
+}
+
+// CloudCommit is a commit in a Bitbucket Cloud hook.
+type CloudCommit struct {
+	Hash    string `json:"hash"`
+	Message string `json:"message"`
+}
+
+// CloudRef is a branch or tag in a Bitbucket Cloud push.
+type CloudRef struct {
+	Type   string      `json:"type"`
+	Name   string      `json:"name"`
+	Target CloudCommit `json:"target"`
+}
+
+// CloudChange is one of the changes in a Bitbucket Cloud push.
+type CloudChange struct {
+	New     *CloudRef `json:"new"`
+	Old     *CloudRef `json:"old"`
+	Created bool      `json:"created"`
+	Closed  bool      `json:"closed"`
+	Forced  bool      `json:"forced"`
+	// Commits are the most recent commits in the change, newest first,
+	// Bitbucket truncates this to the last five commits.
+	Commits []CloudCommit `json:"commits"`
+}
+
+// CloudPushHook is the body of a Bitbucket Cloud "repo:push" hook.
+type CloudPushHook struct {
+	Push struct {
+		Changes []CloudChange `json:"changes"`
+	} `json:"push"`
+	Repository CloudRepository `json:"repository"`
+}
+
+// CloudPullRequestEndpoint is the source or destination of a Bitbucket Cloud
+// pull request.
+type CloudPullRequestEndpoint struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Commit     CloudCommit     `json:"commit"`
+	Repository CloudRepository `json:"repository"`
+}
+
+// CloudPullRequestHook is the body of a Bitbucket Cloud "pullrequest:*" hook.
+type CloudPullRequestHook struct {
+	PullRequest struct {
+		ID          int                      `json:"id"`
+		Title       string                   `json:"title"`
+		Description string                   `json:"description"`
+		Draft       bool                     `json:"draft"`
+		Source      CloudPullRequestEndpoint `json:"source"`
+		Destination CloudPullRequestEndpoint `json:"destination"`
+	} `json:"pullrequest"`
+	Repository CloudRepository `json:"repository"`
+}
+
+// ServerRepository is a Bitbucket Server repository.
+type ServerRepository struct {
+	Slug    string `json:"slug"`
+	Project struct {
+		Key string `json:"key"`
+	} `json:"project"`
+}
+
+// FullName returns the "PROJECT/slug" name for the repository.
+func (r ServerRepository) FullName() string {
+	return r.Project.Key + "/" + r.Slug
+}
+
+// ServerChange is one of the changes in a Bitbucket Server push.
+type ServerChange struct {
+	Ref struct {
+		ID        string `json:"id"`
+		DisplayID string `json:"displayId"`
+		Type      string `json:"type"`
+	} `json:"ref"`
+	RefID    string `json:"refId"`
+	FromHash string `json:"fromHash"`
+	ToHash   string `json:"toHash"`
+	Type     string `json:"type"`
+}
+
+// ServerPushHook is the body of a Bitbucket Server "repo:refs_changed" hook.
+type ServerPushHook struct {
+	EventKey   string           `json:"eventKey"`
+	Repository ServerRepository `json:"repository"`
+	Changes    []ServerChange   `json:"changes"`
+}
+
+// ServerPullRequestRef is the source or destination of a Bitbucket Server
+// pull request.
+type ServerPullRequestRef struct {
+	ID           string           `json:"id"`
+	DisplayID    string           `json:"displayId"`
+	LatestCommit string           `json:"latestCommit"`
+	Repository   ServerRepository `json:"repository"`
+}
+
+// ServerPullRequestHook is the body of a Bitbucket Server "pr:*" hook.
+type ServerPullRequestHook struct {
+	EventKey    string `json:"eventKey"`
+	PullRequest struct {
+		ID          int                  `json:"id"`
+		Title       string               `json:"title"`
+		Description string               `json:"description"`
+		Draft       bool                 `json:"draft"`
+		FromRef     ServerPullRequestRef `json:"fromRef"`
+		ToRef       ServerPullRequestRef `json:"toRef"`
+	} `json:"pullRequest"`
+}
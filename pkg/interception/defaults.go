@@ -0,0 +1,61 @@
+package interception
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/bigkevmcd/interceptor/pkg/interception/bitbucket"
+	"github.com/bigkevmcd/interceptor/pkg/interception/gitea"
+	"github.com/bigkevmcd/interceptor/pkg/interception/gitlab"
+	"github.com/bigkevmcd/interceptor/pkg/interception/issuecomment"
+	"github.com/bigkevmcd/interceptor/pkg/interception/pullrequest"
+	"github.com/bigkevmcd/interceptor/pkg/interception/push"
+)
+
+// RegisterDefaults registers the built-in Interceptors for each of the
+// providers, these can be replaced by registering a different Interceptor
+// for the provider and event-type.
+func RegisterDefaults(s *Server) {
+	s.Register(GitHubProvider, "issue_comment", InterceptorFunc(issuecomment.Intercept))
+	s.Register(GitHubProvider, "pull_request", InterceptorFunc(pullrequest.Intercept))
+	s.Register(GitHubProvider, "push", InterceptorFunc(push.Intercept))
+
+	s.Register(GitLabProvider, gitlab.PushEvent, InterceptorFunc(gitlab.InterceptPush))
+	s.Register(GitLabProvider, gitlab.TagPushEvent, InterceptorFunc(gitlab.InterceptPush))
+	s.Register(GitLabProvider, gitlab.MergeRequestEvent, InterceptorFunc(gitlab.InterceptMergeRequest))
+
+	s.Register(GiteaProvider, gitea.PushEvent, InterceptorFunc(gitea.InterceptPush))
+	s.Register(GiteaProvider, gitea.PullRequestEvent, InterceptorFunc(gitea.InterceptPullRequest))
+
+	s.Register(BitbucketProvider, bitbucket.CloudPushEvent, InterceptorFunc(bitbucket.InterceptPush))
+	s.Register(BitbucketProvider, bitbucket.ServerPushEvent, InterceptorFunc(bitbucket.InterceptPush))
+	for _, k := range bitbucket.CloudPullRequestEvents {
+		s.Register(BitbucketProvider, k, InterceptorFunc(bitbucket.InterceptPullRequest))
+	}
+	for _, k := range bitbucket.ServerPullRequestEvents {
+		s.Register(BitbucketProvider, k, InterceptorFunc(bitbucket.InterceptPullRequest))
+	}
+}
+
+// DefaultServer creates a Server with the options, and the built-in
+// Interceptors registered, see RegisterDefaults.
+func DefaultServer(opts ...Option) *Server {
+	s := NewServer(opts...)
+	RegisterDefaults(s)
+	return s
+}
+
+var (
+	defaultServerOnce sync.Once
+	defaultServer     *Server
+)
+
+// Handler processes interception requests with the built-in Interceptors.
+//
+// Deprecated: use a Server, e.g. from DefaultServer.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	defaultServerOnce.Do(func() {
+		defaultServer = DefaultServer()
+	})
+	defaultServer.ServeHTTP(w, r)
+}
@@ -0,0 +1,49 @@
+package interception
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegisterDefaults(t *testing.T) {
+	s := NewServer()
+	RegisterDefaults(s)
+
+	registeredTests := []struct {
+		provider  string
+		eventType string
+	}{
+		{GitHubProvider, "push"},
+		{GitHubProvider, "pull_request"},
+		{GitHubProvider, "issue_comment"},
+		{GitLabProvider, "Push Hook"},
+		{GitLabProvider, "Tag Push Hook"},
+		{GitLabProvider, "Merge Request Hook"},
+		{GiteaProvider, "push"},
+		{GiteaProvider, "pull_request"},
+		{BitbucketProvider, "repo:push"},
+		{BitbucketProvider, "repo:refs_changed"},
+		{BitbucketProvider, "pullrequest:created"},
+		{BitbucketProvider, "pr:opened"},
+	}
+
+	for _, tt := range registeredTests {
+		if _, ok := s.Lookup(tt.provider, tt.eventType); !ok {
+			t.Errorf("no Interceptor registered for %s event %q", tt.provider, tt.eventType)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := makePullRequestRequest(t, []byte(`{"action":"closed","repository":{"full_name":"testing/testing"}}`))
+	r.Header.Set("Pullrequest-Action", "opened")
+	r.Header.Set("Pullrequest-Repo", "testing/testing")
+	w := httptest.NewRecorder()
+
+	Handler(w, r)
+
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("unexpected status code, got %d, wanted %d", w.Code, http.StatusPreconditionFailed)
+	}
+}
@@ -0,0 +1,83 @@
+package interception
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/expression"
+)
+
+const (
+	// FilterHeader is a CEL expression that must return true for an allowed
+	// hook to be passed on.
+	FilterHeader = "Interceptor-Filter"
+
+	// OverlayHeader is a key=expression pair, the result of the CEL
+	// expression is added to "intercepted" as the key, there can be multiple
+	// overlay headers.
+	OverlayHeader = "Interceptor-Overlay"
+)
+
+// applyExpressions evaluates the filter and overlay expressions in the
+// request headers for an allowed decision.
+//
+// If the filter returns false, the hook is denied, otherwise the results of
+// the overlays are added to the intercepted values.
+//
+// The body is decoded once, and only if there are expressions to evaluate.
+func applyExpressions(e *expression.Evaluator, r *http.Request, body []byte, d *decision.Decision) (*decision.Decision, error) {
+	filter, overlays := r.Header.Get(FilterHeader), r.Header[OverlayHeader]
+	if filter == "" && len(overlays) == 0 {
+		return d, nil
+	}
+	in, err := expression.NewInput(body, r.Header)
+	if err != nil {
+		return nil, err
+	}
+	if filter != "" {
+		ok, err := e.FilterInput(filter, in)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return decision.Denied(decision.FilterRejected), nil
+		}
+	}
+
+	for _, v := range overlays {
+		key, expr, err := parseOverlay(v)
+		if err != nil {
+			return nil, err
+		}
+		result, err := e.EvaluateInput(expr, in)
+		if err != nil {
+			return nil, err
+		}
+		if d.Intercepted == nil {
+			d.Intercepted = map[string]interface{}{}
+		}
+		d.Intercepted[key] = result
+	}
+	return d, nil
+}
+
+// parseOverlay splits an overlay into the key and the expression.
+func parseOverlay(s string) (string, string, error) {
+	key, expr, ok := splitKeyValue(s)
+	if !ok {
+		return "", "", fmt.Errorf("invalid overlay %q, must be key=expression", s)
+	}
+	return key, expr, nil
+}
+
+// splitKeyValue splits a key=value pair on the first "=", and trims the key
+// and value, both must be non-empty.
+func splitKeyValue(s string) (string, string, bool) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+		return "", "", false
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+}
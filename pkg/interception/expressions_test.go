@@ -0,0 +1,32 @@
+package interception
+
+import (
+	"testing"
+)
+
+func TestParseOverlay(t *testing.T) {
+	overlayTests := []struct {
+		overlay  string
+		wantKey  string
+		wantExpr string
+		wantErr  bool
+	}{
+		{"base=body.pull_request.base.ref", "base", "body.pull_request.base.ref", false},
+		{" draft = body.pull_request.draft == true", "draft", "body.pull_request.draft == true", false},
+		{"body.pull_request.base.ref", "", "", true},
+		{"=body.pull_request.base.ref", "", "", true},
+		{"base=", "", "", true},
+	}
+
+	for _, tt := range overlayTests {
+		t.Run(tt.overlay, func(t *testing.T) {
+			key, expr, err := parseOverlay(tt.overlay)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseOverlay() got error %v, wanted error %v", err, tt.wantErr)
+			}
+			if key != tt.wantKey || expr != tt.wantExpr {
+				t.Fatalf("parseOverlay() got %q, %q, wanted %q, %q", key, expr, tt.wantKey, tt.wantExpr)
+			}
+		})
+	}
+}
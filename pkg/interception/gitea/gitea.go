@@ -0,0 +1,45 @@
+package gitea
+
+import "net/http"
+
+// Gitea and Forgejo hooks are GitHub-like, but are identified, and signed
+// with their own headers.
+//
+// See https://docs.gitea.com/usage/webhooks
+
+const (
+	// EventHeader is the header Gitea uses to identify the hook event.
+	EventHeader = "X-Gitea-Event"
+	// SignatureHeader is the header Gitea uses for the hex-encoded
+	// HMAC-SHA256 signature of the body.
+	SignatureHeader = "X-Gitea-Signature"
+
+	// ForgejoEventHeader is the header Forgejo uses to identify the hook
+	// event.
+	ForgejoEventHeader = "X-Forgejo-Event"
+	// ForgejoSignatureHeader is the header Forgejo uses for the hex-encoded
+	// HMAC-SHA256 signature of the body.
+	ForgejoSignatureHeader = "X-Forgejo-Signature"
+
+	// PushEvent is the event for pushes.
+	PushEvent = "push"
+	// PullRequestEvent is the event for pull requests.
+	PullRequestEvent = "pull_request"
+)
+
+// EventType returns the Gitea or Forgejo event for the request, or an empty
+// string if this is not a Gitea or Forgejo hook.
+func EventType(r *http.Request) string {
+	if et := r.Header.Get(ForgejoEventHeader); et != "" {
+		return et
+	}
+	return r.Header.Get(EventHeader)
+}
+
+// Signature returns the Gitea or Forgejo signature for the request.
+func Signature(r *http.Request) string {
+	if sig := r.Header.Get(ForgejoSignatureHeader); sig != "" {
+		return sig
+	}
+	return r.Header.Get(SignatureHeader)
+}
@@ -0,0 +1,121 @@
+package gitea
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v28/github"
+	"go.uber.org/zap"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/forks"
+	"github.com/bigkevmcd/interceptor/pkg/interception/pullrequest"
+	"github.com/bigkevmcd/interceptor/pkg/interception/push"
+	"github.com/bigkevmcd/interceptor/pkg/logging"
+	"github.com/bigkevmcd/interceptor/pkg/skip"
+)
+
+// InterceptPush is an Interceptor that checks that the Gitea or Forgejo push
+// hook body matches the requested fields.
+//
+// It recognises the same request headers as the GitHub push handler, and
+// adds the same "intercepted" values to the body.
+func InterceptPush(r *http.Request, body []byte) (*decision.Decision, error) {
+	if EventType(r) != PushEvent {
+		logging.Decision(r.Context(), decision.EventMismatch)
+		return decision.Denied(decision.EventMismatch), nil
+	}
+	var event github.PushEvent
+	err := json.Unmarshal(body, &event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request body: %w", err)
+	}
+
+	e := push.Event(&event)
+	fields := []zap.Field{zap.String("repo", e.Repo), zap.String("ref", event.GetRef())}
+	opts := skip.FromRequest(r)
+	match, reason, err := push.MatchEventWithSkipOptions(r, e, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error matching push: %w", err)
+	}
+	if !match {
+		logging.Decision(r.Context(), reason, opts.DecisionFields(e, reason, fields...)...)
+		return decision.Denied(reason), nil
+	}
+	files, match, err := push.MatchPaths(r, &event)
+	if err != nil {
+		return nil, fmt.Errorf("error matching paths: %w", err)
+	}
+	if !match {
+		logging.Decision(r.Context(), decision.PathsNotMatched, fields...)
+		return decision.Denied(decision.PathsNotMatched), nil
+	}
+	logging.Decision(r.Context(), decision.Matched, fields...)
+	intercepted := push.InterceptedValues(&event)
+	if files != nil {
+		intercepted["changed_files"] = files
+	}
+	return decision.AllowedEvent(e, intercepted), nil
+}
+
+// PushHandler is an InterceptionFunc that returns the body with the
+// intercepted values from InterceptPush, or nil if the push doesn't match.
+func PushHandler(r *http.Request, body []byte) ([]byte, error) {
+	d, err := InterceptPush(r, body)
+	if err != nil {
+		return nil, err
+	}
+	return d.Apply(body)
+}
+
+// InterceptPullRequest is an Interceptor that checks that the Gitea or
+// Forgejo pull_request hook body matches the requested fields.
+//
+// It recognises the same request headers as the GitHub pull_request handler,
+// and adds the same "intercepted" values to the body.
+//
+// The Pullrequest-Action is matched against the Gitea action, e.g. opened,
+// closed, reopened, edited or synchronized.
+//
+// The authors of pull requests from forks can't be checked, so hooks are
+// rejected with an error if a Pullrequest-Fork-Policy other than "allow" is
+// configured, see forks.RequireAllow.
+func InterceptPullRequest(r *http.Request, body []byte) (*decision.Decision, error) {
+	if EventType(r) != PullRequestEvent {
+		logging.Decision(r.Context(), decision.EventMismatch)
+		return decision.Denied(decision.EventMismatch), nil
+	}
+	if err := forks.RequireAllow(r, "gitea"); err != nil {
+		return nil, err
+	}
+	var event github.PullRequestEvent
+	err := json.Unmarshal(body, &event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request body: %w", err)
+	}
+
+	e := pullrequest.Event(&event)
+	opts := skip.FromRequest(r)
+	match, reason, err := pullrequest.MatchEventWithSkipOptions(r, e, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error matching pull request: %w", err)
+	}
+	logging.Decision(r.Context(), reason, opts.DecisionFields(e, reason,
+		zap.String("repo", e.Repo), zap.String("action", e.Action))...)
+	if !match {
+		return decision.Denied(reason), nil
+	}
+	return decision.AllowedEvent(e, pullrequest.InterceptedValues(&event)), nil
+}
+
+// PullRequestHandler is an InterceptionFunc that returns the body with the
+// intercepted values from InterceptPullRequest, or nil if the pull request
+// doesn't match.
+func PullRequestHandler(r *http.Request, body []byte) ([]byte, error) {
+	d, err := InterceptPullRequest(r, body)
+	if err != nil {
+		return nil, err
+	}
+	return d.Apply(body)
+}
@@ -0,0 +1,203 @@
+package gitea
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bigkevmcd/interceptor/pkg/interception/internal/hooktest"
+)
+
+const (
+	testPush = `{
+  "ref": "refs/heads/master",
+  "before": "000000000000",
+  "after": "abc123456789",
+  "commits": [{"id": "abc123456789", "message": "testing"}],
+  "repository": {"full_name": "testing/testing"}
+}`
+
+	testPullRequest = `{
+  "action": "opened",
+  "number": 1,
+  "pull_request": {
+    "number": 1,
+    "base": {"ref": "master", "repo": {"full_name": "testing/testing"}},
+    "head": {"sha": "abc123456789", "ref": "my-branch", "repo": {"full_name": "octocat/testing"}}
+  },
+  "repository": {"full_name": "testing/testing"}
+}`
+)
+
+func TestPushHandler(t *testing.T) {
+	pushTests := []struct {
+		name        string
+		eventHeader string
+		eventType   string
+		ref         string
+		wantMatch   bool
+	}{
+		{"gitea push", EventHeader, PushEvent, "master", true},
+		{"forgejo push", ForgejoEventHeader, PushEvent, "master", true},
+		{"other branch", EventHeader, PushEvent, "my-branch", false},
+		{"other event", EventHeader, PullRequestEvent, "master", false},
+	}
+
+	for _, tt := range pushTests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(testPush)
+			r := hooktest.MakeRequest(body, tt.eventHeader, tt.eventType, map[string]string{
+				"Push-Repo": "testing/testing",
+				"Push-Ref":  tt.ref,
+			})
+
+			newBody, err := PushHandler(r, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.wantMatch {
+				if newBody != nil {
+					t.Fatalf("PushHandler() got %s, wanted nil", newBody)
+				}
+				return
+			}
+			hooktest.AssertIntercepted(t, newBody, map[string]string{
+				"ref":       "master",
+				"short_sha": "abc123",
+				"fullname":  "testing/testing",
+			})
+		})
+	}
+}
+
+func TestPushHandlerWithRefChanges(t *testing.T) {
+	const zeroSHA = "0000000000000000000000000000000000000000"
+	changeTests := []struct {
+		name        string
+		before      string
+		after       string
+		headers     map[string]string
+		wantMatch   bool
+		wantCreated string
+		wantDeleted string
+	}{
+		{"created", zeroSHA, "abc123456789", nil, true, "true", "false"},
+		{"created and not matched", zeroSHA, "abc123456789", map[string]string{"Push-On-Create": "false"}, false, "", ""},
+		{"deleted", "abc123456789", zeroSHA, nil, false, "", ""},
+		{"deleted and matched", "abc123456789", zeroSHA, map[string]string{"Push-On-Delete": "true"}, true, "false", "true"},
+		{"updated", "def123456789", "abc123456789", nil, true, "false", "false"},
+	}
+
+	for _, tt := range changeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(fmt.Sprintf(`{"ref": "refs/heads/master", "before": %q, "after": %q, "repository": {"full_name": "testing/testing"}}`, tt.before, tt.after))
+			headers := map[string]string{"Push-Repo": "testing/testing"}
+			for k, v := range tt.headers {
+				headers[k] = v
+			}
+			r := hooktest.MakeRequest(body, EventHeader, PushEvent, headers)
+
+			newBody, err := PushHandler(r, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match := newBody != nil; match != tt.wantMatch {
+				t.Fatalf("PushHandler() got match %v, wanted %v", match, tt.wantMatch)
+			}
+			if !tt.wantMatch {
+				return
+			}
+			hooktest.AssertIntercepted(t, newBody, map[string]string{
+				"created": tt.wantCreated,
+				"deleted": tt.wantDeleted,
+			})
+		})
+	}
+}
+
+func TestPullRequestHandler(t *testing.T) {
+	prTests := []struct {
+		name      string
+		action    string
+		baseRef   string
+		wantMatch bool
+	}{
+		{"matching action", "opened,synchronized", "", true},
+		{"other action", "closed", "", false},
+		{"matching base ref", "opened", "glob:mast*", true},
+		{"other base ref", "opened", "develop", false},
+	}
+
+	for _, tt := range prTests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(testPullRequest)
+			r := hooktest.MakeRequest(body, EventHeader, PullRequestEvent, map[string]string{
+				"Pullrequest-Repo":     "testing/testing",
+				"Pullrequest-Action":   tt.action,
+				"Pullrequest-Base-Ref": tt.baseRef,
+			})
+
+			newBody, err := PullRequestHandler(r, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.wantMatch {
+				if newBody != nil {
+					t.Fatalf("PullRequestHandler() got %s, wanted nil", newBody)
+				}
+				return
+			}
+			hooktest.AssertIntercepted(t, newBody, map[string]string{
+				"short_sha":          "abc123",
+				"fullname":           "testing/testing",
+				"number":             "1",
+				"base_ref":           "master",
+				"head_ref":           "my-branch",
+				"head_repo_fullname": "octocat/testing",
+				"is_fork":            "true",
+			})
+		})
+	}
+}
+
+func TestPullRequestHandlerWithForkPolicy(t *testing.T) {
+	policyTests := []struct {
+		policy  string
+		wantErr string
+	}{
+		{"allow", ""},
+		{"reject", `fork policy "reject" is not supported for provider gitea`},
+		{"ok-to-test", `fork policy "ok-to-test" is not supported for provider gitea`},
+	}
+
+	for _, tt := range policyTests {
+		t.Run(tt.policy, func(t *testing.T) {
+			body := []byte(testPullRequest)
+			r := hooktest.MakeRequest(body, EventHeader, PullRequestEvent, map[string]string{
+				"Pullrequest-Repo":        "testing/testing",
+				"Pullrequest-Action":      "opened",
+				"Pullrequest-Fork-Policy": tt.policy,
+			})
+
+			newBody, err := PullRequestHandler(r, body)
+			if tt.wantErr == "" {
+				if err != nil || newBody == nil {
+					t.Fatalf("PullRequestHandler() got %s, %v, wanted a match", newBody, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("PullRequestHandler() got error %v, wanted %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHandlersWithInvalidJSON(t *testing.T) {
+	body := []byte(`{test`)
+	if _, err := PushHandler(hooktest.MakeRequest(body, EventHeader, PushEvent, nil), body); err == nil {
+		t.Error("PushHandler: expected json parsing error, got nil")
+	}
+	if _, err := PullRequestHandler(hooktest.MakeRequest(body, EventHeader, PullRequestEvent, nil), body); err == nil {
+		t.Error("PullRequestHandler: expected json parsing error, got nil")
+	}
+}
@@ -0,0 +1,116 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/forks"
+	"github.com/bigkevmcd/interceptor/pkg/interception/pullrequest"
+	"github.com/bigkevmcd/interceptor/pkg/logging"
+	"github.com/bigkevmcd/interceptor/pkg/skip"
+)
+
+// InterceptMergeRequest is an Interceptor that checks that the GitLab merge
+// request hook body matches the requested fields.
+//
+// It recognises the same request headers as the GitHub pull_request handler:
+//    X-Gitlab-Event - this is provided by GitLab in its hook-mechanism
+//    Pullrequest-Action - this is configured on the trigger interceptor, and
+//    is matched against the GitLab action e.g. open, update, merge.
+//    Pullrequest-Repo - this is the full path of the GitLab project e.g.
+//    gitlab-org/gitlab.
+//    Pullrequest-Base-Ref - patterns for the target branch.
+//    Pullrequest-Head-Ref - patterns for the source branch.
+//    Pullrequest-Include-Drafts, Pullrequest-Labels and
+//    Pullrequest-Exclude-Labels - these are matched against the draft status
+//    and labels of the merge request.
+//
+// The authors of merge requests from forks can't be checked, so hooks are
+// rejected with an error if a Pullrequest-Fork-Policy other than "allow" is
+// configured, see forks.RequireAllow.
+//
+// If the request matches the configuration, the hook is allowed with the same
+// "intercepted" values as the GitHub pull_request handler.
+func InterceptMergeRequest(r *http.Request, body []byte) (*decision.Decision, error) {
+	if r.Header.Get(EventHeader) != MergeRequestEvent {
+		logging.Decision(r.Context(), decision.EventMismatch)
+		return decision.Denied(decision.EventMismatch), nil
+	}
+	if err := forks.RequireAllow(r, "gitlab"); err != nil {
+		return nil, err
+	}
+	var hook MergeRequestHook
+	err := json.Unmarshal(body, &hook)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request body: %w", err)
+	}
+	if hook.ObjectAttributes == nil {
+		logging.Decision(r.Context(), decision.EventMismatch,
+			zap.String("repo", hook.Project.PathWithNamespace))
+		return decision.Denied(decision.EventMismatch), nil
+	}
+
+	e := mergeRequestEvent(&hook)
+	opts := skip.FromRequest(r)
+	match, reason, err := pullrequest.MatchEventWithSkipOptions(r, e, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error matching merge request: %w", err)
+	}
+	logging.Decision(r.Context(), reason, opts.DecisionFields(e, reason,
+		zap.String("repo", e.Repo), zap.String("action", e.Action))...)
+	if !match {
+		return decision.Denied(reason), nil
+	}
+
+	return decision.AllowedEvent(e, pullrequest.EventValues(e)), nil
+}
+
+// MergeRequestHandler is an InterceptionFunc that returns the body with the intercepted
+// values from InterceptMergeRequest, or nil if the merge request doesn't match.
+func MergeRequestHandler(r *http.Request, body []byte) ([]byte, error) {
+	d, err := InterceptMergeRequest(r, body)
+	if err != nil {
+		return nil, err
+	}
+	return d.Apply(body)
+}
+
+// mergeRequestEvent returns the normalised event for a merge request, the
+// hook must have object attributes.
+func mergeRequestEvent(hook *MergeRequestHook) *event.Event {
+	a := hook.ObjectAttributes
+	return &event.Event{
+		Type:        event.PullRequest,
+		Repo:        hook.Project.PathWithNamespace,
+		Action:      a.Action,
+		SHA:         lastCommitID(a),
+		Number:      a.IID,
+		Title:       a.Title,
+		Description: a.Description,
+		BaseRef:     a.TargetBranch,
+		HeadRef:     a.SourceBranch,
+		HeadRepo:    a.Source.PathWithNamespace,
+		Draft:       a.Draft || a.WorkInProgress,
+		Labels:      labelTitles(hook.Labels),
+	}
+}
+
+func labelTitles(labels []Label) []string {
+	var titles []string
+	for _, l := range labels {
+		titles = append(titles, l.Title)
+	}
+	return titles
+}
+
+func lastCommitID(a *MergeRequestAttributes) string {
+	if a.LastCommit == nil {
+		return ""
+	}
+	return a.LastCommit.ID
+}
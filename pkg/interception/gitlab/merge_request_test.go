@@ -0,0 +1,200 @@
+package gitlab
+
+import (
+	"testing"
+
+	"github.com/bigkevmcd/interceptor/pkg/interception/internal/hooktest"
+)
+
+func TestMergeRequestHandlerWithSuccess(t *testing.T) {
+	body := hooktest.MustMarshal(t, makeMergeRequestHook("open"))
+	r := hooktest.MakeRequest(body, EventHeader, MergeRequestEvent, map[string]string{
+		"Pullrequest-Repo":   testFullname,
+		"Pullrequest-Action": "open,update",
+	})
+
+	newBody, err := MergeRequestHandler(r, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hooktest.AssertIntercepted(t, newBody, map[string]string{
+		"short_sha":          "abc123",
+		"fullname":           testFullname,
+		"number":             "1",
+		"base_ref":           "master",
+		"head_ref":           "my-branch",
+		"head_repo_fullname": testFullname,
+		"is_fork":            "false",
+	})
+}
+
+func TestMergeRequestHandlerWithRefs(t *testing.T) {
+	refTests := []struct {
+		name      string
+		baseRef   string
+		headRef   string
+		wantMatch bool
+	}{
+		{"matching refs", "master", "glob:my-*", true},
+		{"other base ref", "glob:release/*", "", false},
+		{"other head ref", "", "glob:fix/*", false},
+	}
+
+	for _, tt := range refTests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := hooktest.MustMarshal(t, makeMergeRequestHook("open"))
+			r := hooktest.MakeRequest(body, EventHeader, MergeRequestEvent, map[string]string{
+				"Pullrequest-Repo":     testFullname,
+				"Pullrequest-Action":   "open",
+				"Pullrequest-Base-Ref": tt.baseRef,
+				"Pullrequest-Head-Ref": tt.headRef,
+			})
+
+			newBody, err := MergeRequestHandler(r, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match := newBody != nil; match != tt.wantMatch {
+				t.Fatalf("MergeRequestHandler() got match %v, wanted %v", match, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestMergeRequestHandlerWithDifferentAction(t *testing.T) {
+	body := hooktest.MustMarshal(t, makeMergeRequestHook("close"))
+	r := hooktest.MakeRequest(body, EventHeader, MergeRequestEvent, map[string]string{
+		"Pullrequest-Repo":   testFullname,
+		"Pullrequest-Action": "open,update",
+	})
+
+	newBody, err := MergeRequestHandler(r, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newBody != nil {
+		t.Fatalf("MergeRequestHandler() got %s, wanted nil", newBody)
+	}
+}
+
+func TestMergeRequestHandlerWithDifferentRepo(t *testing.T) {
+	body := hooktest.MustMarshal(t, makeMergeRequestHook("open"))
+	r := hooktest.MakeRequest(body, EventHeader, MergeRequestEvent, map[string]string{
+		"Pullrequest-Repo":   "testing/other",
+		"Pullrequest-Action": "open",
+	})
+
+	newBody, err := MergeRequestHandler(r, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newBody != nil {
+		t.Fatalf("MergeRequestHandler() got %s, wanted nil", newBody)
+	}
+}
+
+func TestMergeRequestHandlerWithDraftsAndLabels(t *testing.T) {
+	labelTests := []struct {
+		name      string
+		draft     bool
+		labels    []Label
+		headers   map[string]string
+		wantMatch bool
+	}{
+		{"draft", true, nil, nil, false},
+		{"draft included", true, nil, map[string]string{"Pullrequest-Include-Drafts": "true"}, true},
+		{"labelled", false, []Label{{Title: "run-e2e"}}, map[string]string{"Pullrequest-Labels": "run-e2e"}, true},
+		{"not labelled", false, []Label{{Title: "bug"}}, map[string]string{"Pullrequest-Labels": "run-e2e"}, false},
+		{"excluded label", false, []Label{{Title: "hold"}}, map[string]string{"Pullrequest-Exclude-Labels": "hold"}, false},
+	}
+
+	for _, tt := range labelTests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := makeMergeRequestHook("open")
+			hook.ObjectAttributes.Draft = tt.draft
+			hook.Labels = tt.labels
+			body := hooktest.MustMarshal(t, hook)
+			headers := map[string]string{
+				"Pullrequest-Repo":   testFullname,
+				"Pullrequest-Action": "open",
+			}
+			for k, v := range tt.headers {
+				headers[k] = v
+			}
+			r := hooktest.MakeRequest(body, EventHeader, MergeRequestEvent, headers)
+
+			newBody, err := MergeRequestHandler(r, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match := newBody != nil; match != tt.wantMatch {
+				t.Fatalf("MergeRequestHandler() got match %v, wanted %v", match, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestMergeRequestHandlerWithForkPolicy(t *testing.T) {
+	policyTests := []struct {
+		policy  string
+		wantErr string
+	}{
+		{"allow", ""},
+		{"reject", `fork policy "reject" is not supported for provider gitlab`},
+		{"ok-to-test", `fork policy "ok-to-test" is not supported for provider gitlab`},
+	}
+
+	for _, tt := range policyTests {
+		t.Run(tt.policy, func(t *testing.T) {
+			body := hooktest.MustMarshal(t, makeMergeRequestHook("open"))
+			r := hooktest.MakeRequest(body, EventHeader, MergeRequestEvent, map[string]string{
+				"Pullrequest-Repo":        testFullname,
+				"Pullrequest-Action":      "open",
+				"Pullrequest-Fork-Policy": tt.policy,
+			})
+
+			newBody, err := MergeRequestHandler(r, body)
+			if tt.wantErr == "" {
+				if err != nil || newBody == nil {
+					t.Fatalf("MergeRequestHandler() got %s, %v, wanted a match", newBody, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("MergeRequestHandler() got error %v, wanted %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMergeRequestHandlerWithInvalidJSON(t *testing.T) {
+	body := []byte(`{test`)
+	r := hooktest.MakeRequest(body, EventHeader, MergeRequestEvent, map[string]string{})
+
+	_, err := MergeRequestHandler(r, body)
+	if err == nil {
+		t.Fatal("expected json parsing error, got nil")
+	}
+}
+
+func makeMergeRequestHook(action string) *MergeRequestHook {
+	return &MergeRequestHook{
+		ObjectKind: "merge_request",
+		Project: Project{
+			PathWithNamespace: testFullname,
+		},
+		ObjectAttributes: &MergeRequestAttributes{
+			IID:          1,
+			Action:       action,
+			SourceBranch: "my-branch",
+			TargetBranch: "master",
+			LastCommit: &Commit{
+				ID: "abc123456789",
+			},
+			Source: Project{
+				PathWithNamespace: testFullname,
+			},
+		},
+	}
+}
@@ -0,0 +1,99 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/git"
+	"github.com/bigkevmcd/interceptor/pkg/interception/push"
+	"github.com/bigkevmcd/interceptor/pkg/logging"
+	"github.com/bigkevmcd/interceptor/pkg/naming"
+	"github.com/bigkevmcd/interceptor/pkg/skip"
+)
+
+// InterceptPush is an Interceptor that checks that the GitLab push hook body
+// matches the requested fields.
+//
+// It recognises the same request headers as the GitHub push handler:
+//    X-Gitlab-Event - this is provided by GitLab in its hook-mechanism
+//    Push-Ref - this is configured on the trigger interceptor
+//    PushExclude-Ref - this is configured on the trigger interceptor
+//    Push-Repo - this is the full path of the GitLab project e.g.
+//    gitlab-org/gitlab.
+//
+// If the request matches the configuration, the hook is allowed with the same
+// "intercepted" values as the GitHub push handler.
+func InterceptPush(r *http.Request, body []byte) (*decision.Decision, error) {
+	if et := r.Header.Get(EventHeader); et != PushEvent && et != TagPushEvent {
+		logging.Decision(r.Context(), decision.EventMismatch)
+		return decision.Denied(decision.EventMismatch), nil
+	}
+	var hook PushHook
+	err := json.Unmarshal(body, &hook)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request body: %w", err)
+	}
+
+	e := pushEvent(&hook)
+	opts := skip.FromRequest(r)
+	match, reason, err := push.MatchEventWithSkipOptions(r, e, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error matching push: %w", err)
+	}
+	logging.Decision(r.Context(), reason,
+		opts.DecisionFields(e, reason, zap.String("repo", e.Repo), zap.String("ref", hook.Ref))...)
+	if !match {
+		return decision.Denied(reason), nil
+	}
+
+	intercepted := map[string]interface{}{
+		"ref":       e.Ref,
+		"short_sha": git.ShortenSHA(e.SHA),
+		"fullname":  hook.Project.PathWithNamespace,
+	}
+	push.AddTagValues(intercepted, hook.Ref)
+	push.AddRefChangeValues(intercepted, e)
+	naming.AddValues(intercepted, e)
+	return decision.AllowedEvent(e, intercepted), nil
+}
+
+// PushHandler is an InterceptionFunc that returns the body with the intercepted
+// values from InterceptPush, or nil if the push doesn't match.
+func PushHandler(r *http.Request, body []byte) ([]byte, error) {
+	d, err := InterceptPush(r, body)
+	if err != nil {
+		return nil, err
+	}
+	return d.Apply(body)
+}
+
+// pushEvent returns the normalised event for a push, the head commit is the
+// commit that the ref was updated to.
+//
+// GitLab doesn't indicate whether a push was forced, so these are never
+// treated as force pushes.
+func pushEvent(hook *PushHook) *event.Event {
+	e := &event.Event{
+		Type:    event.Push,
+		Repo:    hook.Project.PathWithNamespace,
+		Ref:     git.BranchName(hook.Ref),
+		Sender:  hook.UserUsername,
+		SHA:     hook.After,
+		Before:  hook.Before,
+		After:   hook.After,
+		Created: git.IsZeroSHA(hook.Before),
+		Deleted: git.IsZeroSHA(hook.After),
+	}
+	for _, c := range hook.Commits {
+		if c.ID == hook.After {
+			e.HeadCommitMessage = c.Message
+		}
+		e.CommitMessages = append(e.CommitMessages, c.Message)
+	}
+	return e
+}
@@ -0,0 +1,182 @@
+package gitlab
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/bigkevmcd/interceptor/pkg/interception/internal/hooktest"
+)
+
+const (
+	testFullname = "testing/testing"
+)
+
+func TestPushHandlerWithSuccess(t *testing.T) {
+	for _, et := range []string{PushEvent, TagPushEvent} {
+		hook := makePushHook("refs/heads/master")
+		body := hooktest.MustMarshal(t, hook)
+		r := hooktest.MakeRequest(body, EventHeader, et, map[string]string{
+			"Push-Repo": testFullname,
+			"Push-Ref":  "master",
+		})
+
+		newBody, err := PushHandler(r, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hooktest.AssertIntercepted(t, newBody, map[string]string{
+			"ref":       "master",
+			"short_sha": "abc123",
+			"fullname":  testFullname,
+		})
+		returnBody, err := sjson.DeleteBytes(newBody, "intercepted")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(returnBody, body) {
+			t.Fatalf("handler got incorrect body: got %s, wanted %s", newBody, body)
+		}
+	}
+}
+
+func TestPushHandlerWithUnmatchedRef(t *testing.T) {
+	hook := makePushHook("refs/heads/my-branch")
+	body := hooktest.MustMarshal(t, hook)
+	r := hooktest.MakeRequest(body, EventHeader, PushEvent, map[string]string{
+		"Push-Repo": testFullname,
+		"Push-Ref":  "master",
+	})
+
+	newBody, err := PushHandler(r, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newBody != nil {
+		t.Fatalf("PushHandler() got %s, wanted nil", newBody)
+	}
+}
+
+func TestPushHandlerWithOtherEvent(t *testing.T) {
+	body := hooktest.MustMarshal(t, makePushHook("refs/heads/master"))
+	r := hooktest.MakeRequest(body, EventHeader, MergeRequestEvent, map[string]string{
+		"Push-Repo": testFullname,
+	})
+
+	newBody, err := PushHandler(r, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newBody != nil {
+		t.Fatalf("PushHandler() got %s, wanted nil", newBody)
+	}
+}
+
+func TestPushHandlerWithInvalidJSON(t *testing.T) {
+	body := []byte(`{test`)
+	r := hooktest.MakeRequest(body, EventHeader, PushEvent, map[string]string{})
+
+	_, err := PushHandler(r, body)
+	if err == nil {
+		t.Fatal("expected json parsing error, got nil")
+	}
+}
+
+func makePushHook(ref string) *PushHook {
+	return &PushHook{
+		ObjectKind: "push",
+		Ref:        ref,
+		After:      "abc123456789",
+		Project: Project{
+			PathWithNamespace: testFullname,
+		},
+	}
+}
+
+func TestPushHandlerWithSkipMarker(t *testing.T) {
+	hook := makePushHook("refs/heads/master")
+	hook.Commits = []Commit{
+		{ID: "def456789012", Message: "[skip ci] Fix typo"},
+		{ID: "abc123456789", Message: "Update the docs"},
+	}
+	skipTests := []struct {
+		name    string
+		headers map[string]string
+		skipped bool
+	}{
+		{"head commit", nil, false},
+		{"all commits", map[string]string{"Interceptor-Skip-All-Commits": "true"}, true},
+		{"disabled", map[string]string{"Interceptor-Skip-All-Commits": "true", "Interceptor-Skip-Disabled": "true"}, false},
+	}
+
+	for _, tt := range skipTests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := hooktest.MustMarshal(t, hook)
+			headers := map[string]string{"Push-Repo": testFullname}
+			for k, v := range tt.headers {
+				headers[k] = v
+			}
+			r := hooktest.MakeRequest(body, EventHeader, PushEvent, headers)
+
+			newBody, err := PushHandler(r, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if skipped := newBody == nil; skipped != tt.skipped {
+				t.Fatalf("PushHandler() skipped got %v, wanted %v", skipped, tt.skipped)
+			}
+		})
+	}
+}
+
+func TestPushHandlerWithRefChanges(t *testing.T) {
+	zeroSHA := "0000000000000000000000000000000000000000"
+	changeTests := []struct {
+		name        string
+		before      string
+		after       string
+		headers     map[string]string
+		wantCreated bool
+		wantDeleted bool
+		wantMatch   bool
+	}{
+		{"created", zeroSHA, "abc123456789", nil, true, false, true},
+		{"deleted", "abc123456789", zeroSHA, nil, false, true, false},
+		{"deleted included", "abc123456789", zeroSHA, map[string]string{"Push-On-Delete": "true"}, false, true, true},
+	}
+
+	for _, tt := range changeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := makePushHook("refs/heads/master")
+			hook.Before = tt.before
+			hook.After = tt.after
+			body := hooktest.MustMarshal(t, hook)
+			headers := map[string]string{"Push-Repo": testFullname}
+			for k, v := range tt.headers {
+				headers[k] = v
+			}
+			r := hooktest.MakeRequest(body, EventHeader, PushEvent, headers)
+
+			newBody, err := PushHandler(r, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.wantMatch {
+				if newBody != nil {
+					t.Fatalf("PushHandler() got %s, wanted nil", newBody)
+				}
+				return
+			}
+			if got := gjson.GetBytes(newBody, "intercepted.created").Bool(); got != tt.wantCreated {
+				t.Errorf("intercepted.created got %v, wanted %v", got, tt.wantCreated)
+			}
+			if got := gjson.GetBytes(newBody, "intercepted.deleted").Bool(); got != tt.wantDeleted {
+				t.Errorf("intercepted.deleted got %v, wanted %v", got, tt.wantDeleted)
+			}
+			hooktest.AssertIntercepted(t, newBody, map[string]string{"before": tt.before, "after": tt.after})
+		})
+	}
+}
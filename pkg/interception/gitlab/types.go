@@ -0,0 +1,83 @@
+package gitlab
+
+// These are the subset of the GitLab hook payloads that are needed to
+// match hooks.
+//
+// See https://docs.gitlab.com/ee/user/project/integrations/webhooks.html
+
+const (
+	// EventHeader is the header GitLab uses to identify the hook event.
+	EventHeader = "X-Gitlab-Event"
+	// TokenHeader is the header GitLab sends the configured secret token in.
+	TokenHeader = "X-Gitlab-Token"
+
+	// PushEvent is the X-Gitlab-Event for branch pushes.
+	PushEvent = "Push Hook"
+	// TagPushEvent is the X-Gitlab-Event for tag pushes.
+	TagPushEvent = "Tag Push Hook"
+	// MergeRequestEvent is the X-Gitlab-Event for merge requests.
+	MergeRequestEvent = "Merge Request Hook"
+)
+
+// Project is the project that the hook is for.
+type Project struct {
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+}
+
+// Commit is a commit in a push or merge request hook.
+type Commit struct {
+	ID       string   `json:"id"`
+	Message  string   `json:"message"`
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
+// PushHook is the body of a "Push Hook" or "Tag Push Hook".
+type PushHook struct {
+	ObjectKind   string   `json:"object_kind"`
+	Before       string   `json:"before"`
+	After        string   `json:"after"`
+	Ref          string   `json:"ref"`
+	CheckoutSHA  string   `json:"checkout_sha"`
+	UserUsername string   `json:"user_username"`
+	Project      Project  `json:"project"`
+	Commits      []Commit `json:"commits"`
+}
+
+// User is the user that triggered a hook.
+type User struct {
+	Username string `json:"username"`
+}
+
+// MergeRequestAttributes describes the merge request in a merge request hook.
+type MergeRequestAttributes struct {
+	IID          int     `json:"iid"`
+	Action       string  `json:"action"`
+	State        string  `json:"state"`
+	Title        string  `json:"title"`
+	Description  string  `json:"description"`
+	SourceBranch string  `json:"source_branch"`
+	TargetBranch string  `json:"target_branch"`
+	LastCommit   *Commit `json:"last_commit"`
+	Source       Project `json:"source"`
+	// Draft replaced WorkInProgress in GitLab 13.2, older versions only
+	// send WorkInProgress.
+	Draft          bool `json:"draft"`
+	WorkInProgress bool `json:"work_in_progress"`
+}
+
+// Label is a label on a merge request.
+type Label struct {
+	Title string `json:"title"`
+}
+
+// MergeRequestHook is the body of a "Merge Request Hook".
+type MergeRequestHook struct {
+	ObjectKind       string                  `json:"object_kind"`
+	User             User                    `json:"user"`
+	Project          Project                 `json:"project"`
+	ObjectAttributes *MergeRequestAttributes `json:"object_attributes"`
+	Labels           []Label                 `json:"labels"`
+}
@@ -1,64 +1,74 @@
 package interception
 
 import (
-	"fmt"
-	"io/ioutil"
-	"log"
 	"net/http"
 
-	"github.com/bigkevmcd/interceptor/pkg/interception/pullrequest"
-	"github.com/bigkevmcd/interceptor/pkg/interception/push"
+	"github.com/tidwall/gjson"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/interception/bitbucket"
+	"github.com/bigkevmcd/interceptor/pkg/interception/gitea"
+	"github.com/bigkevmcd/interceptor/pkg/interception/gitlab"
 )
 
 const (
 	gitHubEventHeader = "X-Github-Event"
+
+	// ReasonHeader is the response header with the reason for the
+	// interception decision.
+	ReasonHeader = "X-Interceptor-Reason"
 )
 
-// eventHandlerMap is a mapping from GitHub hook events to handlers.
-var eventHandlerMap = map[string]InterceptionFunc{
-	"pull_request": pullrequest.Handler,
-	"push":         push.Handler,
+// These are the providers that hooks can come from.
+const (
+	GitHubProvider    = "github"
+	GitLabProvider    = "gitlab"
+	BitbucketProvider = "bitbucket"
+	GiteaProvider     = "gitea"
+)
+
+// writeDenied writes the response for a rejected hook, with the reason in
+// the header and body.
+func writeDenied(w http.ResponseWriter, d *decision.Decision) {
+	status := d.Status
+	if status == 0 {
+		status = http.StatusPreconditionFailed
+	}
+	msg := "failed interception: " + string(d.Reason)
+	if d.Message != "" {
+		msg += ": " + d.Message
+	}
+	w.Header().Set(ReasonHeader, string(d.Reason))
+	http.Error(w, msg, status)
 }
 
-// Handler processes interception requests.
+// hookEvent returns the provider and event-type for the request.
 //
-// Extracting the event-type from the GitHub hook event header.
-//
-// If we don't have a handler for this event-type, the handler returns
-// the body, and a successful response, allowing unknown events through.
-//
-// Otherwise, they're passed to a handler to decide whether or not to
-// allow the interception to complete.
-func Handler(w http.ResponseWriter, r *http.Request) {
-	eventType := r.Header.Get(gitHubEventHeader)
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		msg := fmt.Sprintf("failed to read the request body: %s", err.Error())
-		http.Error(w, msg, http.StatusBadRequest)
-		return
+// Gitea also sends the X-Github-Event header, so it's checked before GitHub.
+func hookEvent(r *http.Request) (string, string) {
+	if eventType := gitea.EventType(r); eventType != "" {
+		return GiteaProvider, eventType
 	}
-	h, ok := eventHandlerMap[eventType]
-
-	if !ok {
-		log.Printf("failed to handle event %s\n", eventType)
-		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
-		w.Write(body)
-		return
+	if eventType := r.Header.Get(gitlab.EventHeader); eventType != "" {
+		return GitLabProvider, eventType
 	}
-
-	log.Printf("handling event %s\n", eventType)
-	newBody, err := h(r, body)
-	if err != nil {
-		msg := fmt.Sprintf("failed handling the event: %s", err.Error())
-		http.Error(w, msg, http.StatusInternalServerError)
-		return
+	if eventType := r.Header.Get(bitbucket.EventHeader); eventType != "" {
+		return BitbucketProvider, eventType
 	}
+	return GitHubProvider, r.Header.Get(gitHubEventHeader)
+}
 
-	if len(newBody) > 0 {
-		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
-		w.Write(newBody)
-		return
+// hookRepoName extracts the full name of the repository from the hook body.
+func hookRepoName(r *http.Request, body []byte) string {
+	if r.Header.Get(gitlab.EventHeader) != "" {
+		return gjson.GetBytes(body, "project.path_with_namespace").String()
 	}
-
-	http.Error(w, "failed interception", http.StatusPreconditionFailed)
+	if eventKey := r.Header.Get(bitbucket.EventHeader); bitbucket.IsServerEvent(eventKey) {
+		repo := gjson.GetBytes(body, "repository")
+		if !repo.Exists() {
+			repo = gjson.GetBytes(body, "pullRequest.toRef.repository")
+		}
+		return repo.Get("project.key").String() + "/" + repo.Get("slug").String()
+	}
+	return gjson.GetBytes(body, "repository.full_name").String()
 }
@@ -1,8 +1,50 @@
 package interception
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+)
 
 // InterceptionFunc returns the response body, and possibly an error.
 // If the response body is nil, this will be returned to the client as an error,
 // indicating that it should not continue.
+//
+// Deprecated: InterceptionFunc can't explain why a hook was rejected, use an
+// Interceptor, InterceptionFuncs can be adapted with FromInterceptionFunc.
 type InterceptionFunc func(r *http.Request, body []byte) ([]byte, error)
+
+// Interceptor decides whether or not a hook should be passed on.
+//
+// An error indicates that the hook couldn't be handled, rather than that the
+// hook was rejected.
+type Interceptor interface {
+	Intercept(r *http.Request, body []byte) (*decision.Decision, error)
+}
+
+// InterceptorFunc is an adapter to allow ordinary functions to be used as
+// Interceptors.
+type InterceptorFunc func(r *http.Request, body []byte) (*decision.Decision, error)
+
+// Intercept calls f(r, body).
+func (f InterceptorFunc) Intercept(r *http.Request, body []byte) (*decision.Decision, error) {
+	return f(r, body)
+}
+
+// FromInterceptionFunc adapts an InterceptionFunc to an Interceptor.
+//
+// If the InterceptionFunc returns an empty body, the hook is denied with
+// the reason decision.NotMatched, otherwise the returned body replaces the
+// hook body.
+func FromInterceptionFunc(f InterceptionFunc) Interceptor {
+	return InterceptorFunc(func(r *http.Request, body []byte) (*decision.Decision, error) {
+		newBody, err := f(r, body)
+		if err != nil {
+			return nil, err
+		}
+		if len(newBody) == 0 {
+			return decision.Denied(decision.NotMatched), nil
+		}
+		return &decision.Decision{Allow: true, Reason: decision.Matched, Body: newBody}, nil
+	})
+}
@@ -0,0 +1,46 @@
+// Package hooktest provides helpers for testing the hook handlers for the
+// different providers.
+package hooktest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// MakeRequest returns a hook request with the body, the event type in the
+// event header, and the additional headers.
+func MakeRequest(body []byte, eventHeader, eventType string, headers map[string]string) *http.Request {
+	r, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+	r.Header.Add("Content-Type", "application/json")
+	r.Header.Add(eventHeader, eventType)
+	for k, v := range headers {
+		r.Header.Add(k, v)
+	}
+	return r
+}
+
+// AssertIntercepted fails the test if the values in the "intercepted" key of
+// the body don't match the wanted values.
+func AssertIntercepted(t *testing.T, body []byte, want map[string]string) {
+	t.Helper()
+	for k, v := range want {
+		if got := gjson.GetBytes(body, "intercepted."+k).String(); got != v {
+			t.Errorf("intercepted.%s got %q, wanted %q", k, got, v)
+		}
+	}
+}
+
+// MustMarshal returns the JSON encoding of v, failing the test if it can't be
+// encoded.
+func MustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	body, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
@@ -0,0 +1,76 @@
+package issuecomment
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v28/github"
+	"go.uber.org/zap"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/logging"
+)
+
+// Intercept is an Interceptor that checks that the GitHub issue_comment
+// request body is a ChatOps command on a pull request.
+//
+// It recognises the following request headers:
+//    X-GitHub-Event - this is provided by GitHub in its hook-mechanism
+//    Issuecomment-Repo - this is the full name of the GitHub repo e.g.
+//    tektoncd/triggers, or a comma-separated list of repos.
+//    Issuecomment-Command - a comma-separated list of commands e.g.
+//    "/ok-to-test,/retest"
+//    Issuecomment-Author-Association - a comma-separated list of the author
+//    associations that are allowed to issue commands, this defaults to
+//    "OWNER,MEMBER,COLLABORATOR".
+//
+// Only newly created comments on pull requests are matched.
+//
+// If the request matches the configuration, the hook is allowed with
+// "intercepted.number", "intercepted.command", "intercepted.args" and
+// "intercepted.fullname".
+func Intercept(r *http.Request, body []byte) (*decision.Decision, error) {
+	var event github.IssueCommentEvent
+	err := json.Unmarshal(body, &event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal request body: %w", err)
+	}
+
+	cmd, reason := MatchCommentCommand(r, &event)
+	logging.Decision(r.Context(), reason,
+		zap.String("repo", event.GetRepo().GetFullName()), zap.String("action", event.GetAction()))
+	if cmd == nil {
+		return decision.Denied(reason), nil
+	}
+
+	intercepted := map[string]interface{}{
+		"number":   event.GetIssue().GetNumber(),
+		"command":  cmd.Name,
+		"args":     cmd.Args,
+		"fullname": event.GetRepo().GetFullName(),
+	}
+	return decision.AllowedEvent(commentEvent(&event), intercepted), nil
+}
+
+// Handler is an InterceptionFunc that returns the body with the intercepted
+// values from Intercept, or nil if the comment doesn't match.
+func Handler(r *http.Request, body []byte) ([]byte, error) {
+	d, err := Intercept(r, body)
+	if err != nil {
+		return nil, err
+	}
+	return d.Apply(body)
+}
+
+// commentEvent returns the normalised event for a comment.
+func commentEvent(e *github.IssueCommentEvent) *event.Event {
+	return &event.Event{
+		Type:   event.IssueComment,
+		Repo:   e.GetRepo().GetFullName(),
+		Action: e.GetAction(),
+		Sender: e.GetSender().GetLogin(),
+		Number: e.GetIssue().GetNumber(),
+	}
+}
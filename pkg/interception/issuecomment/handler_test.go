@@ -0,0 +1,66 @@
+package issuecomment
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func TestHandleWithSuccess(t *testing.T) {
+	body, err := json.Marshal(makeHookBody("created", "/retest unit", "MEMBER", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := makeRequest(issueCommentEventType, nil)
+
+	newBody, err := Handler(r, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	intercepted := gjson.GetBytes(newBody, "intercepted").Value()
+	want := map[string]interface{}{
+		"number":   float64(12),
+		"command":  "/retest",
+		"args":     []interface{}{"unit"},
+		"fullname": testFullname,
+	}
+	if !reflect.DeepEqual(intercepted, want) {
+		t.Errorf("intercepted got %#v, wanted %#v", intercepted, want)
+	}
+
+	// Delete the addition to simplify the return comparison.
+	returnBody, err := sjson.DeleteBytes(newBody, "intercepted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(returnBody, body) {
+		t.Fatalf("handler got incorrect body: got %s, wanted %s", newBody, body)
+	}
+}
+
+func TestHandleWithNoMatch(t *testing.T) {
+	body, err := json.Marshal(makeHookBody("created", "/approve", "MEMBER", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := makeRequest(issueCommentEventType, nil)
+
+	newBody, err := Handler(r, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newBody != nil {
+		t.Fatalf("Handler() got %s, wanted nil", newBody)
+	}
+}
+
+func TestHandleWithInvalidJSON(t *testing.T) {
+	_, err := Handler(makeRequest(issueCommentEventType, nil), []byte(`{test`))
+	if err == nil {
+		t.Fatal("expected json parsing error, got nil")
+	}
+}
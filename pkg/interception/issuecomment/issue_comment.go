@@ -0,0 +1,94 @@
+package issuecomment
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/headers"
+)
+
+const (
+	gitHubEventHeader             = "X-Github-Event"
+	issueCommentEventType         = "issue_comment"
+	issueCommentRepoHeader        = "Issuecomment-Repo"
+	issueCommentCommandHeader     = "Issuecomment-Command"
+	issueCommentAssociationHeader = "Issuecomment-Author-Association"
+
+	createdAction = "created"
+)
+
+// defaultAssociations are the author associations that are allowed to issue
+// commands if no Issuecomment-Author-Association header is provided.
+var defaultAssociations = []string{"OWNER", "MEMBER", "COLLABORATOR"}
+
+// Command is a command parsed from a comment e.g. "/retest unit" is the
+// command "/retest" with the argument "unit".
+type Command struct {
+	Name string
+	Args []string
+}
+
+// MatchCommentCommand will match on newly created comments on pull requests
+// in the repository provided in the Issuecomment-Repo header, if the comment
+// contains one of the commands in the Issuecomment-Command header, and the
+// commenter has one of the author associations in the
+// Issuecomment-Author-Association header.
+//
+// If the comment matches, the matching command is returned, along with the
+// reason for the decision.
+func MatchCommentCommand(r *http.Request, event *github.IssueCommentEvent) (*Command, decision.Reason) {
+	if r.Header.Get(gitHubEventHeader) != issueCommentEventType {
+		return nil, decision.EventMismatch
+	}
+	if event.GetAction() != createdAction {
+		return nil, decision.CommentNotCreated
+	}
+	if event.GetIssue().PullRequestLinks == nil {
+		return nil, decision.NotPullRequest
+	}
+	if !contains(headers.SplitList(r.Header.Get(issueCommentRepoHeader)), event.GetRepo().GetFullName()) {
+		return nil, decision.RepoMismatch
+	}
+	if !contains(wantedAssociations(r), event.GetComment().GetAuthorAssociation()) {
+		return nil, decision.AuthorNotAllowed
+	}
+	cmd := findCommand(headers.SplitList(r.Header.Get(issueCommentCommandHeader)), event.GetComment().GetBody())
+	if cmd == nil {
+		return nil, decision.CommandNotFound
+	}
+	return cmd, decision.Matched
+}
+
+// findCommand returns the first line in the comment that starts with one of
+// the commands.
+func findCommand(commands []string, body string) *Command {
+	for _, line := range strings.Split(body, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if contains(commands, fields[0]) {
+			return &Command{Name: fields[0], Args: fields[1:]}
+		}
+	}
+	return nil
+}
+
+func wantedAssociations(r *http.Request) []string {
+	if h := r.Header.Get(issueCommentAssociationHeader); h != "" {
+		return headers.SplitList(strings.ToUpper(h))
+	}
+	return defaultAssociations
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
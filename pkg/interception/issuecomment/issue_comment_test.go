@@ -0,0 +1,149 @@
+package issuecomment
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+)
+
+const (
+	testFullname = "testing/testing"
+)
+
+func TestMatchCommentCommand(t *testing.T) {
+	matchTests := []struct {
+		name        string
+		event       *github.IssueCommentEvent
+		headers     map[string]string
+		wantCommand *Command
+		wantReason  decision.Reason
+	}{
+		{
+			"matching command",
+			makeHookBody("created", "/retest", "MEMBER", true),
+			nil,
+			&Command{Name: "/retest", Args: []string{}},
+			decision.Matched,
+		},
+		{
+			"command with arguments",
+			makeHookBody("created", "Looks good\n/retest unit e2e", "OWNER", true),
+			nil,
+			&Command{Name: "/retest", Args: []string{"unit", "e2e"}},
+			decision.Matched,
+		},
+		{
+			"unknown command",
+			makeHookBody("created", "/approve", "OWNER", true),
+			nil,
+			nil,
+			decision.CommandNotFound,
+		},
+		{
+			"command not at start of line",
+			makeHookBody("created", "please /retest", "OWNER", true),
+			nil,
+			nil,
+			decision.CommandNotFound,
+		},
+		{
+			"edited comment",
+			makeHookBody("edited", "/retest", "OWNER", true),
+			nil,
+			nil,
+			decision.CommentNotCreated,
+		},
+		{
+			"comment on issue",
+			makeHookBody("created", "/retest", "OWNER", false),
+			nil,
+			nil,
+			decision.NotPullRequest,
+		},
+		{
+			"contributor",
+			makeHookBody("created", "/retest", "CONTRIBUTOR", true),
+			nil,
+			nil,
+			decision.AuthorNotAllowed,
+		},
+		{
+			"configured association",
+			makeHookBody("created", "/retest", "CONTRIBUTOR", true),
+			map[string]string{issueCommentAssociationHeader: "owner, contributor"},
+			&Command{Name: "/retest", Args: []string{}},
+			decision.Matched,
+		},
+		{
+			"other repo",
+			makeHookBody("created", "/retest", "OWNER", true),
+			map[string]string{issueCommentRepoHeader: "testing/other"},
+			nil,
+			decision.RepoMismatch,
+		},
+	}
+
+	for _, tt := range matchTests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := makeRequest(issueCommentEventType, tt.headers)
+
+			cmd, reason := MatchCommentCommand(r, tt.event)
+
+			if !reflect.DeepEqual(cmd, tt.wantCommand) {
+				t.Fatalf("MatchCommentCommand() got %#v, wanted %#v", cmd, tt.wantCommand)
+			}
+			if reason != tt.wantReason {
+				t.Fatalf("MatchCommentCommand() got reason %q, wanted %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestMatchCommentCommandWithOtherEvent(t *testing.T) {
+	r := makeRequest("pull_request", nil)
+
+	cmd, reason := MatchCommentCommand(r, makeHookBody("created", "/retest", "OWNER", true))
+
+	if cmd != nil || reason != decision.EventMismatch {
+		t.Fatalf("MatchCommentCommand() got %#v, %q, wanted nil, %q", cmd, reason, decision.EventMismatch)
+	}
+}
+
+func makeHookBody(action, comment, association string, onPullRequest bool) *github.IssueCommentEvent {
+	event := &github.IssueCommentEvent{
+		Action: github.String(action),
+		Issue: &github.Issue{
+			Number: github.Int(12),
+		},
+		Comment: &github.IssueComment{
+			Body:              github.String(comment),
+			AuthorAssociation: github.String(association),
+		},
+		Repo: &github.Repository{
+			FullName: github.String(testFullname),
+		},
+	}
+	if onPullRequest {
+		event.Issue.PullRequestLinks = &github.PullRequestLinks{
+			URL: github.String("https://api.github.com/repos/testing/testing/pulls/12"),
+		}
+	}
+	return event
+}
+
+func makeRequest(eventType string, headers map[string]string) *http.Request {
+	r, _ := http.NewRequest("POST", "/", bytes.NewReader([]byte(`{}`)))
+	r.Header.Add("Content-Type", "application/json")
+	r.Header.Add(gitHubEventHeader, eventType)
+	r.Header.Add(issueCommentRepoHeader, testFullname)
+	r.Header.Add(issueCommentCommandHeader, "/ok-to-test,/retest")
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	return r
+}
@@ -0,0 +1,39 @@
+package interception
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// deliveryHeaders are the headers that providers use to identify a hook
+// delivery, these are checked in order.
+var deliveryHeaders = []string{
+	"X-GitHub-Delivery",
+	"X-Gitea-Delivery",
+	"X-Gitlab-Event-UUID",
+	"X-Request-UUID",
+	"X-Request-Id",
+}
+
+// deliveryID returns the provider's identifier for the hook delivery, or an
+// empty string if there isn't one.
+func deliveryID(r *http.Request) string {
+	for _, h := range deliveryHeaders {
+		if v := r.Header.Get(h); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// requestFields returns the log fields that identify the hook in the
+// request.
+func requestFields(r *http.Request) []zap.Field {
+	provider, eventType := hookEvent(r)
+	return []zap.Field{
+		zap.String("delivery", deliveryID(r)),
+		zap.String("provider", provider),
+		zap.String("event", eventType),
+	}
+}
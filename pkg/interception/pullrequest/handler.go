@@ -2,48 +2,167 @@ package pullrequest
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/google/go-github/v28/github"
-	"github.com/tidwall/sjson"
+	"go.uber.org/zap"
 
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/forks"
 	"github.com/bigkevmcd/interceptor/pkg/git"
+	"github.com/bigkevmcd/interceptor/pkg/logging"
+	"github.com/bigkevmcd/interceptor/pkg/naming"
+	"github.com/bigkevmcd/interceptor/pkg/skip"
 )
 
-// Handler is an InterceptionFunc that checks that the GitHub request
-// body matches the requested fields.
+// Intercept is an Interceptor that checks that the GitHub request body
+// matches the requested fields.
 //
 // It recognises the following request headers:
 //    X-GitHub-Event - this is provided by GitHub in its hook-mechanism
 //    Pullrequest-Action - this is configured on the trigger interceptor
 //    Pullrequest-Repo - this is the full name of the GitHub repo e.g.
-//    tektoncd/triggers.
+//    tektoncd/triggers, or a comma-separated list of repos.
+//    Pullrequest-Base-Ref - patterns for the branch the pull request will be
+//    merged into.
+//    Pullrequest-Head-Ref - patterns for the branch with the changes.
+//    Pullrequest-Include-Drafts - draft pull requests only match if this is
+//    "true".
+//    Pullrequest-Labels - the pull request must have one of these labels.
+//    Pullrequest-Exclude-Labels - the pull request must have none of these
+//    labels.
 //
-// If the request matches the configuration, the body is returned.
-func Handler(r *http.Request, body []byte) ([]byte, error) {
+// Pull requests with a skip marker like "[skip ci]" in the title or
+// description are rejected, see skip.FromRequest for the headers that
+// configure this.
+//
+// If the request matches the configuration, the hook is allowed with the
+// "intercepted" values.
+//
+// Intercept doesn't check pull requests from forks, and returns an error if
+// a Pullrequest-Fork-Policy is configured, use an Interceptor for this.
+func Intercept(r *http.Request, body []byte) (*decision.Decision, error) {
+	return intercept(r, body, nil)
+}
+
+// Interceptor is an Interceptor for GitHub pull_request hooks that behaves
+// like Intercept, and checks the authors of pull requests from forks.
+//
+// The Pullrequest-Fork-Policy header configures what happens to pull
+// requests from forks:
+//    allow - these match without checking the author, this is the default.
+//    reject - these are rejected unless the author is a collaborator on the
+//    repository, or a member of the organisation that owns it.
+//    ok-to-test - as with reject, but these also match if a trusted user has
+//    commented "/ok-to-test <sha>" with the head SHA on the pull request.
+type Interceptor struct {
+	forks *forks.Checker
+}
+
+// NewInterceptor creates an Interceptor that checks the authors of pull
+// requests from forks with the checker.
+func NewInterceptor(c *forks.Checker) *Interceptor {
+	return &Interceptor{forks: c}
+}
+
+// Intercept implements the interception.Interceptor interface.
+func (i *Interceptor) Intercept(r *http.Request, body []byte) (*decision.Decision, error) {
+	return intercept(r, body, i.forks)
+}
+
+func intercept(r *http.Request, body []byte, checker *forks.Checker) (*decision.Decision, error) {
 	var event github.PullRequestEvent
 	err := json.Unmarshal(body, &event)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal request body: %w", err)
 	}
 
-	match, err := MatchPullRequestAction(r, body)
+	e := Event(&event)
+	opts := skip.FromRequest(r)
+	match, reason, err := matchPullRequest(r, e, opts)
 	if err != nil {
 		return nil, fmt.Errorf("error matching pull request: %w", err)
 	}
+	if match {
+		reason, err = checkFork(r, checker, e)
+		if err != nil {
+			return nil, fmt.Errorf("error checking pull request from fork: %w", err)
+		}
+		match = reason == decision.Matched
+	}
+	logging.Decision(r.Context(), reason, opts.DecisionFields(e, reason,
+		zap.String("repo", e.Repo), zap.String("action", e.Action))...)
 	if !match {
-		return nil, nil
+		return decision.Denied(reason), nil
 	}
+	return decision.AllowedEvent(e, InterceptedValues(&event)), nil
+}
 
-	intercepted := map[string]interface{}{
-		"short_sha": git.ShortenSHA(strValue(event.PullRequest.Head.SHA)),
-		"fullname":  event.Repo.FullName,
+// checkFork applies the Pullrequest-Fork-Policy to a matching pull request,
+// pull requests that aren't from forks always match.
+func checkFork(r *http.Request, checker *forks.Checker, e *event.Event) (decision.Reason, error) {
+	policy, err := forks.FromRequest(r)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", forks.PolicyHeader, err)
+	}
+	if policy == forks.Allow || !e.IsFork() {
+		return decision.Matched, nil
 	}
-	body, err = sjson.SetBytes(body, "intercepted", intercepted)
+	if checker == nil {
+		return "", errors.New("fork checks are not configured")
+	}
+	trusted, err := checker.Trusted(r.Context(), e.Repo, e.Author)
+	if err != nil {
+		return "", err
+	}
+	if trusted {
+		return decision.Matched, nil
+	}
+	if policy == forks.Reject {
+		return decision.UntrustedFork, nil
+	}
+	ok, err := checker.OKToTest(r.Context(), e.Repo, e.Number, e.SHA)
 	if err != nil {
-		return nil, fmt.Errorf("error setting the intercepted values: %w", err)
+		return "", err
+	}
+	if !ok {
+		return decision.OKToTestRequired, nil
 	}
+	return decision.Matched, nil
+}
 
-	return body, nil
+// Handler is an InterceptionFunc that returns the body with the intercepted
+// values from Intercept, or nil if the pull request doesn't match.
+func Handler(r *http.Request, body []byte) ([]byte, error) {
+	d, err := Intercept(r, body)
+	if err != nil {
+		return nil, err
+	}
+	return d.Apply(body)
+}
+
+// InterceptedValues returns the values that are added to the body of a
+// matching pull request as "intercepted".
+func InterceptedValues(event *github.PullRequestEvent) map[string]interface{} {
+	return EventValues(Event(event))
+}
+
+// EventValues returns the "intercepted" values for a normalised pull request
+// event, these are the same for all providers, and include the
+// Kubernetes-safe names from naming.Values.
+func EventValues(e *event.Event) map[string]interface{} {
+	values := map[string]interface{}{
+		"fullname":           e.Repo,
+		"short_sha":          git.ShortenSHA(e.SHA),
+		"number":             e.Number,
+		"base_ref":           e.BaseRef,
+		"head_ref":           e.HeadRef,
+		"head_repo_fullname": e.HeadRepo,
+		"is_fork":            e.IsFork(),
+	}
+	naming.AddValues(values, e)
+	return values
 }
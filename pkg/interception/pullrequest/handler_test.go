@@ -1,12 +1,19 @@
 package pullrequest
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/google/go-github/v28/github"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/forks"
 )
 
 func TestHandleWithSuccess(t *testing.T) {
@@ -17,8 +24,12 @@ func TestHandleWithSuccess(t *testing.T) {
 			FullName: github.String(repoName),
 		},
 		PullRequest: &github.PullRequest{
+			Number: github.Int(12),
+			Base:   &github.PullRequestBranch{Ref: github.String("main")},
 			Head: &github.PullRequestBranch{
-				SHA: github.String("abc1234567"),
+				SHA:  github.String("abc1234567"),
+				Ref:  github.String("feature/login"),
+				Repo: &github.Repository{FullName: github.String("octocat/testing")},
 			},
 		},
 	}
@@ -38,6 +49,22 @@ func TestHandleWithSuccess(t *testing.T) {
 	if fullName.Value() != repoName {
 		t.Errorf("intercepted.fullname got %s, wanted %s", fullName, repoName)
 	}
+	wantValues := map[string]interface{}{
+		"number":             12.0,
+		"base_ref":           "main",
+		"head_ref":           "feature/login",
+		"head_repo_fullname": "octocat/testing",
+		"is_fork":            true,
+		"repo_name":          "testing-testing",
+		"branch_name":        "feature-login",
+		"branch_label":       "feature-login",
+		"pr_name":            "testing-testing-pr-12",
+	}
+	for k, v := range wantValues {
+		if got := gjson.GetBytes(newBody, "intercepted."+k).Value(); got != v {
+			t.Errorf("intercepted.%s got %#v, wanted %#v", k, got, v)
+		}
+	}
 
 	// Delete the addition to simplify the return comparison.
 	newBody, err = sjson.DeleteBytes(newBody, "intercepted")
@@ -50,3 +77,138 @@ func TestHandleWithSuccess(t *testing.T) {
 	}
 
 }
+
+func TestInterceptorWithForks(t *testing.T) {
+	ts := httptest.NewServer(fakeGitHub())
+	defer ts.Close()
+	client, err := forks.NewClient(ts.URL+"/api/v3/", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := NewInterceptor(forks.NewChecker(client))
+
+	forkTests := []struct {
+		name     string
+		policy   string
+		headRepo string
+		author   string
+		number   int
+		sha      string
+		want     decision.Reason
+	}{
+		{"default policy", "", "octocat/testing", "octocat", 12, approvedSHA, decision.Matched},
+		{"allow", "allow", "octocat/testing", "octocat", 12, approvedSHA, decision.Matched},
+		{"not a fork", "reject", testFullname, "octocat", 12, approvedSHA, decision.Matched},
+		{"untrusted author", "reject", "octocat/testing", "octocat", 12, approvedSHA, decision.UntrustedFork},
+		{"collaborator", "reject", "collaborator/testing", "collaborator", 12, approvedSHA, decision.Matched},
+		{"org member", "reject", "member/testing", "member", 12, approvedSHA, decision.Matched},
+		{"ok-to-test comment ignored", "reject", "octocat/testing", "octocat", 13, approvedSHA, decision.UntrustedFork},
+		{"no ok-to-test comment", "ok-to-test", "octocat/testing", "octocat", 12, approvedSHA, decision.OKToTestRequired},
+		{"ok-to-test comment", "ok-to-test", "octocat/testing", "octocat", 13, approvedSHA, decision.Matched},
+		{"pushed after ok-to-test comment", "ok-to-test", "octocat/testing", "octocat", 13, pushedSHA, decision.OKToTestRequired},
+		{"backdated commit pushed after ok-to-test comment", "ok-to-test", "octocat/testing", "octocat", 13, backdatedSHA, decision.OKToTestRequired},
+		{"trusted author", "ok-to-test", "member/testing", "member", 12, approvedSHA, decision.Matched},
+	}
+
+	for _, tt := range forkTests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, body := makeRequest(t, makeForkHook(tt.headRepo, tt.author, tt.number, tt.sha), "pull_request", "opened")
+			r.Header.Set(forks.PolicyHeader, tt.policy)
+
+			d, err := i.Intercept(r, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Reason != tt.want || d.Allow != (tt.want == decision.Matched) {
+				t.Fatalf("Intercept() got %v, %q, wanted %q", d.Allow, d.Reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterceptorWithForkErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	client, err := forks.NewClient(ts.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errorTests := []struct {
+		name        string
+		interceptor Interceptor
+		policy      string
+		wantErr     string
+	}{
+		{"invalid policy", Interceptor{}, "never", `invalid Pullrequest-Fork-Policy: unknown fork policy "never"`},
+		{"not configured", Interceptor{}, "reject", "fork checks are not configured"},
+		{"API error", *NewInterceptor(forks.NewChecker(client)), "reject", "failed to check if octocat is a collaborator"},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, body := makeRequest(t, makeForkHook("octocat/testing", "octocat", 12, approvedSHA), "pull_request", "opened")
+			r.Header.Set(forks.PolicyHeader, tt.policy)
+
+			_, err := tt.interceptor.Intercept(r, body)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Intercept() got error %v, wanted %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func makeForkHook(headRepo, author string, number int, sha string) *github.PullRequestEvent {
+	return &github.PullRequestEvent{
+		Action: github.String("opened"),
+		Repo:   &github.Repository{FullName: github.String(testFullname)},
+		PullRequest: &github.PullRequest{
+			Number: github.Int(number),
+			User:   &github.User{Login: github.String(author)},
+			Base:   &github.PullRequestBranch{Ref: github.String("main")},
+			Head: &github.PullRequestBranch{
+				Ref:  github.String("feature"),
+				SHA:  github.String(sha),
+				Repo: &github.Repository{FullName: github.String(headRepo)},
+			},
+		},
+	}
+}
+
+const (
+	// approvedSHA is approved by an "/ok-to-test" comment on pull request 13,
+	// pushedSHA was pushed after it, and backdatedSHA was pushed after it with
+	// a committer date from before the comment.
+	approvedSHA  = "6a6bcddc365ca3a38c9055a603c9590a7fae7ca6"
+	pushedSHA    = "0d3c2b5d9e0c1ef1c6e0a41ea8c7d86b3a3dc1f2"
+	backdatedSHA = "9f2b7c41d0e85a36c1e4b7d2a8f05c3e6b19d4a7"
+)
+
+// fakeGitHub serves the GitHub API for testing/testing, where "collaborator"
+// is a collaborator, "member" is an organisation member, and a member has
+// commented "/ok-to-test" and "/ok-to-test <approvedSHA>" on pull request 13.
+func fakeGitHub() http.Handler {
+	mux := http.NewServeMux()
+	noContent := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+	mux.HandleFunc("/api/v3/repos/testing/testing/collaborators/collaborator", noContent)
+	mux.HandleFunc("/api/v3/orgs/testing/members/member", noContent)
+	mux.HandleFunc("/api/v3/repos/testing/testing/issues/13/comments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"author_association":"MEMBER","body":"/ok-to-test","created_at":"2026-10-18T11:00:00Z"},{"author_association":"MEMBER","body":"/ok-to-test %s","created_at":"2026-10-18T11:00:00Z"}]`, approvedSHA[:12])
+	})
+	mux.HandleFunc("/api/v3/repos/testing/testing/issues/12/comments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"author_association":"NONE","body":"/ok-to-test %s","created_at":"2026-10-18T11:00:00Z"}]`, approvedSHA)
+	})
+	commit := func(date string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"commit":{"committer":{"date":%q}}}`, date)
+		}
+	}
+	mux.HandleFunc("/api/v3/repos/testing/testing/commits/"+approvedSHA, commit("2026-10-18T10:00:00Z"))
+	mux.HandleFunc("/api/v3/repos/testing/testing/commits/"+pushedSHA, commit("2026-10-18T12:00:00Z"))
+	mux.HandleFunc("/api/v3/repos/testing/testing/commits/"+backdatedSHA, commit("2026-10-18T09:00:00Z"))
+	return mux
+}
@@ -3,111 +3,148 @@ package pullrequest
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
-	"strings"
 
 	"github.com/google/go-github/v28/github"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/headers"
+	"github.com/bigkevmcd/interceptor/pkg/matcher"
+	"github.com/bigkevmcd/interceptor/pkg/pattern"
+	"github.com/bigkevmcd/interceptor/pkg/skip"
 )
 
 const (
-	gitHubEventHeader       = "X-Github-Event"
-	pullRequestEventType    = "pull_request"
-	pullRequestActionHeader = "Pullrequest-Action"
-	pullRequestRepoHeader   = "Pullrequest-Repo"
+	gitHubEventHeader        = "X-Github-Event"
+	pullRequestEventType     = "pull_request"
+	pullRequestActionHeader  = "Pullrequest-Action"
+	pullRequestRepoHeader    = "Pullrequest-Repo"
+	pullRequestSenderHeader  = "Pullrequest-Sender"
+	pullRequestBaseRefHeader = "Pullrequest-Base-Ref"
+	pullRequestHeadRefHeader = "Pullrequest-Head-Ref"
+
+	pullRequestIncludeDraftsHeader = "Pullrequest-Include-Drafts"
+	pullRequestLabelsHeader        = "Pullrequest-Labels"
+	pullRequestExcludeLabelsHeader = "Pullrequest-Exclude-Labels"
 )
 
 // MatchPullRequestAction will match on pull-request requests if the action
 // matches the action provided in the pullRequestActionHeader.
-func MatchPullRequestAction(r *http.Request, body []byte) (bool, error) {
+//
+// The reason for the decision is returned along with the match.
+func MatchPullRequestAction(r *http.Request, body []byte) (bool, decision.Reason, error) {
 	if !isPullRequestEvent(r) {
-		log.Println("debug: dropping request because not a pull request event")
-		return false, nil
+		return false, decision.EventMismatch, nil
 	}
 
-	hookPullRequest, err := extractHookPullRequest(r, body)
+	var hook github.PullRequestEvent
+	err := json.Unmarshal(body, &hook)
 	if err != nil {
-		return false, fmt.Errorf("failed to create key: %w", err)
-	}
-
-	wantedPullRequest := extractPullRequest(r)
-	if wantedPullRequest == nil {
-		return false, nil
+		return false, "", fmt.Errorf("failed to unmarshal request body: %w", err)
 	}
-	log.Printf("debug: hook = %s, wanted = %s", hookPullRequest, wantedPullRequest)
-	return matchHookAndRequest(hookPullRequest, wantedPullRequest), nil
+	return MatchEvent(r, Event(&hook))
 }
 
-func isPullRequestEvent(r *http.Request) bool {
-	return r.Header.Get(gitHubEventHeader) == pullRequestEventType
+// matchPullRequest is MatchPullRequestAction for an already parsed event.
+func matchPullRequest(r *http.Request, e *event.Event, opts *skip.Options) (bool, decision.Reason, error) {
+	if !isPullRequestEvent(r) {
+		return false, decision.EventMismatch, nil
+	}
+	return MatchEventWithSkipOptions(r, e, opts)
 }
 
-type pullRequest struct {
-	eventType string
-	action    string
-	repoName  string
+// MatchEvent matches the Pullrequest-* headers in the request against a pull
+// request event.
+//
+// The Pullrequest-Base-Ref and Pullrequest-Head-Ref headers can be
+// comma-separated lists of patterns, see pattern.Parse for the syntax, if
+// they're not provided, all branches match.
+//
+// Draft pull requests only match if Pullrequest-Include-Drafts is "true",
+// and the labels on the pull request are matched against the
+// Pullrequest-Labels and Pullrequest-Exclude-Labels headers, see
+// matcher.Labels.
+//
+// Pull requests with a skip marker in the title or description don't match,
+// see skip.FromRequest.
+//
+// This allows hooks from other providers to be matched in the same way as
+// GitHub pull_request hooks.
+func MatchEvent(r *http.Request, e *event.Event) (bool, decision.Reason, error) {
+	return MatchEventWithSkipOptions(r, e, skip.FromRequest(r))
 }
 
-func repoName(e *github.PullRequestEvent) string {
-	if e.Repo == nil {
-		return ""
+// MatchEventWithSkipOptions is MatchEvent with the skip options that were
+// already parsed from the request, so that the same options can log the
+// decision, see skip.Options.DecisionFields.
+func MatchEventWithSkipOptions(r *http.Request, e *event.Event, opts *skip.Options) (bool, decision.Reason, error) {
+	m, err := requestMatcher(r, opts)
+	if err != nil {
+		return false, "", err
 	}
-	return strValue(e.Repo.FullName)
+	match, reason := m.Match(e)
+	return match, reason, nil
 }
 
-func extractPullRequest(r *http.Request) *pullRequest {
-	et := r.Header.Get(gitHubEventHeader)
-	repo := r.Header.Get(pullRequestRepoHeader)
-	action := r.Header.Get(pullRequestActionHeader)
-	if et != pullRequestEventType {
-		return nil
-	}
-
-	return &pullRequest{
-		eventType: et,
-		action:    action,
-		repoName:  repo,
+// Event returns the normalised event for a GitHub pull request.
+func Event(e *github.PullRequestEvent) *event.Event {
+	pr := e.GetPullRequest()
+	return &event.Event{
+		Type:        event.PullRequest,
+		Repo:        e.GetRepo().GetFullName(),
+		Action:      e.GetAction(),
+		Sender:      e.GetSender().GetLogin(),
+		SHA:         pr.GetHead().GetSHA(),
+		Number:      pr.GetNumber(),
+		Author:      pr.GetUser().GetLogin(),
+		Title:       pr.GetTitle(),
+		Description: pr.GetBody(),
+		BaseRef:     pr.GetBase().GetRef(),
+		HeadRef:     pr.GetHead().GetRef(),
+		HeadRepo:    pr.GetHead().GetRepo().GetFullName(),
+		Draft:       pr.GetDraft(),
+		Labels:      labelNames(pr),
+		Label:       e.GetLabel().GetName(),
 	}
 }
 
-func extractHookPullRequest(r *http.Request, body []byte) (*pullRequest, error) {
-	et := r.Header.Get(gitHubEventHeader)
-	var event github.PullRequestEvent
-	err := json.Unmarshal(body, &event)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal request body: %w", err)
+func labelNames(pr *github.PullRequest) []string {
+	if pr == nil {
+		return nil
 	}
-
-	return &pullRequest{
-		eventType: et,
-		action:    strValue(event.Action),
-		repoName:  repoName(&event),
-	}, nil
-}
-
-func strValue(s *string) string {
-	if s == nil {
-		return ""
+	var names []string
+	for _, l := range pr.Labels {
+		names = append(names, l.GetName())
 	}
-	return *s
+	return names
 }
 
-func matchAction(header, action string) bool {
-	headerActions := strings.Split(header, ",")
-	for _, i := range headerActions {
-		if strings.TrimSpace(i) == action {
-			return true
-		}
-	}
-	return false
+func isPullRequestEvent(r *http.Request) bool {
+	return r.Header.Get(gitHubEventHeader) == pullRequestEventType
 }
 
-func matchHookAndRequest(hook, req *pullRequest) bool {
-	if hook.eventType != req.eventType {
-		return false
+// requestMatcher returns a matcher for the headers in the request.
+func requestMatcher(r *http.Request, opts *skip.Options) (matcher.Matcher, error) {
+	baseRefs, err := pattern.ParseList(r.Header.Get(pullRequestBaseRefHeader))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", pullRequestBaseRefHeader, err)
 	}
-	if hook.repoName != req.repoName {
-		return false
+	headRefs, err := pattern.ParseList(r.Header.Get(pullRequestHeadRefHeader))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", pullRequestHeadRefHeader, err)
 	}
-	return matchAction(req.action, hook.action)
+	return matcher.All(
+		matcher.Type(event.PullRequest),
+		matcher.Repo(headers.SplitList(r.Header.Get(pullRequestRepoHeader))),
+		matcher.Action(headers.SplitList(r.Header.Get(pullRequestActionHeader))),
+		matcher.Sender(headers.SplitList(r.Header.Get(pullRequestSenderHeader))),
+		matcher.BaseRef(baseRefs),
+		matcher.HeadRef(headRefs),
+		matcher.Draft(r.Header.Get(pullRequestIncludeDraftsHeader) == "true"),
+		matcher.Labels(
+			headers.SplitList(r.Header.Get(pullRequestLabelsHeader)),
+			headers.SplitList(r.Header.Get(pullRequestExcludeLabelsHeader))),
+		opts.Matcher(),
+	), nil
 }
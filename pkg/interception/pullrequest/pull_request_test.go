@@ -8,6 +8,11 @@ import (
 	"testing"
 
 	"github.com/google/go-github/v28/github"
+	"github.com/tidwall/sjson"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/skip"
 )
 
 const (
@@ -18,14 +23,14 @@ func TestMatchPullRequestActionWithOtherEvent(t *testing.T) {
 	event := &github.PublicEvent{}
 	r, body := makeRequest(t, event, "public", "open")
 
-	matched, err := MatchPullRequestAction(r, body)
+	matched, reason, err := MatchPullRequestAction(r, body)
 
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if matched {
-		t.Fatal("MatchPullRequestAction() got true, wanted false")
+	if matched || reason != decision.EventMismatch {
+		t.Fatalf("MatchPullRequestAction() got %v, %q, wanted false, %q", matched, reason, decision.EventMismatch)
 	}
 }
 
@@ -34,13 +39,13 @@ func TestMatchPullRequestActionWithMatchingAction(t *testing.T) {
 
 	r, body := makeRequest(t, event, "pull_request", "open")
 
-	matched, err := MatchPullRequestAction(r, body)
+	matched, reason, err := MatchPullRequestAction(r, body)
 
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !matched {
-		t.Fatal("MatchPullRequestAction() got false, wanted true")
+	if !matched || reason != decision.Matched {
+		t.Fatalf("MatchPullRequestAction() got %v, %q, wanted true, %q", matched, reason, decision.Matched)
 	}
 }
 
@@ -49,13 +54,13 @@ func TestMatchPullRequestActionWithMultipleActions(t *testing.T) {
 
 	r, body := makeRequest(t, event, "pull_request", "open,synchronize")
 
-	matched, err := MatchPullRequestAction(r, body)
+	matched, reason, err := MatchPullRequestAction(r, body)
 
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !matched {
-		t.Fatal("MatchPullRequestAction() got false, wanted true")
+	if !matched || reason != decision.Matched {
+		t.Fatalf("MatchPullRequestAction() got %v, %q, wanted true, %q", matched, reason, decision.Matched)
 	}
 }
 
@@ -63,82 +68,93 @@ func TestMatchPullRequestActionWithDifferentAction(t *testing.T) {
 	event := makeHookBody("open")
 	r, body := makeRequest(t, event, "pull_request", "closed")
 
-	matched, err := MatchPullRequestAction(r, body)
+	matched, reason, err := MatchPullRequestAction(r, body)
 
 	if err != nil {
 		t.Fatal(err)
 	}
-	if matched {
-		t.Fatalf("MatchPullRequestAction() got true, wanted false")
+	if matched || reason != decision.ActionNotInList {
+		t.Fatalf("MatchPullRequestAction() got %v, %q, wanted false, %q", matched, reason, decision.ActionNotInList)
 	}
 }
 
 func TestMatchPullRequestActionInvalidJSON(t *testing.T) {
 	r, body := makeRequestWithBody([]byte(`{test`), "pull_request", testFullname, "closed")
 
-	_, err := MatchPullRequestAction(r, body)
+	_, _, err := MatchPullRequestAction(r, body)
 	if err == nil {
 		t.Fatal("expected json parsing error, got nil")
 	}
 }
 
-func TestExtractHookPullRequest(t *testing.T) {
-	keyTests := []struct {
-		event    string
-		hookBody interface{}
-		key      *pullRequest
-	}{
-		{
-			"pull_request", &github.PullRequestEvent{
-				Action: github.String("open"),
-				Repo: &github.Repository{
-					FullName: github.String(testFullname),
-				},
-			}, &pullRequest{"pull_request", "open", "testing/testing"},
+func TestEvent(t *testing.T) {
+	hook := &github.PullRequestEvent{
+		Action: github.String("open"),
+		Repo: &github.Repository{
+			FullName: github.String(testFullname),
 		},
-		{
-			"public", &github.PublicEvent{
-				Repo: &github.Repository{
-					FullName: github.String(testFullname),
-				},
-			}, &pullRequest{"public", "", "testing/testing"},
+		Sender: &github.User{Login: github.String("octocat")},
+		Label:  &github.Label{Name: github.String("run-e2e")},
+		PullRequest: &github.PullRequest{
+			Number: github.Int(12),
+			User:   &github.User{Login: github.String("octocat")},
+			Title:  github.String("Add login"),
+			Body:   github.String("Adds the login page."),
+			Draft:  github.Bool(true),
+			Labels: []*github.Label{{Name: github.String("bug")}, {Name: github.String("run-e2e")}},
+			Base:   &github.PullRequestBranch{Ref: github.String("main")},
+			Head: &github.PullRequestBranch{
+				SHA:  github.String("abc1234567"),
+				Ref:  github.String("feature/login"),
+				Repo: &github.Repository{FullName: github.String("octocat/testing")},
+			},
 		},
 	}
+	want := &event.Event{
+		Type:        event.PullRequest,
+		Repo:        testFullname,
+		Action:      "open",
+		Sender:      "octocat",
+		SHA:         "abc1234567",
+		Number:      12,
+		Author:      "octocat",
+		Title:       "Add login",
+		Description: "Adds the login page.",
+		BaseRef:     "main",
+		HeadRef:     "feature/login",
+		HeadRepo:    "octocat/testing",
+		Draft:       true,
+		Labels:      []string{"bug", "run-e2e"},
+		Label:       "run-e2e",
+	}
 
-	for _, tt := range keyTests {
-		k, err := extractHookPullRequest(makeRequest(t, tt.hookBody, tt.event, "open"))
-		if err != nil {
-			t.Errorf("hookKey() failed: %v for case %#v", err, tt.key)
-		}
-
-		if !reflect.DeepEqual(k, tt.key) {
-			t.Errorf("hookKey() got %#v, wanted %#v", k, tt.key)
-		}
+	if e := Event(hook); !reflect.DeepEqual(e, want) {
+		t.Fatalf("Event() got %#v, wanted %#v", e, want)
 	}
 }
 
-func TestRequestKey(t *testing.T) {
-	keyTests := []struct {
-		eventType string
-		repo      string
-		action    string
-		pr        *pullRequest
+func TestMatchEventWithSenders(t *testing.T) {
+	senderTests := []struct {
+		senders string
+		want    bool
+		reason  decision.Reason
 	}{
-		{"pull_request", "testing/test", "open", &pullRequest{"pull_request", "open", "testing/test"}},
-		{"pull_request", "testing/test", "close", &pullRequest{"pull_request", "close", "testing/test"}},
-		{"public", "testing/test", "", nil},
+		{"", true, decision.Matched},
+		{"octocat, hubot", true, decision.Matched},
+		{"dependabot[bot]", false, decision.SenderNotAllowed},
 	}
 
-	for _, tt := range keyTests {
-		r, _ := http.NewRequest("POST", "/testing", bytes.NewReader([]byte(``)))
-		r.Header.Add("Content-Type", "application/json")
-		r.Header.Add(gitHubEventHeader, tt.eventType)
-		r.Header.Add(pullRequestRepoHeader, tt.repo)
-		r.Header.Add(pullRequestActionHeader, tt.action)
+	for _, tt := range senderTests {
+		r, _ := makeRequestWithBody([]byte(`{}`), "pull_request", testFullname, "open")
+		r.Header.Set(pullRequestSenderHeader, tt.senders)
+		hook := &event.Event{Type: event.PullRequest, Repo: testFullname, Action: "open", Sender: "octocat"}
 
-		pr := extractPullRequest(r)
-		if !reflect.DeepEqual(pr, tt.pr) {
-			t.Errorf("requestKey() got %#v wanted %#v", pr, tt.pr)
+		m, reason, err := MatchEvent(r, hook)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != tt.want || reason != tt.reason {
+			t.Errorf("MatchEvent() with senders %q got %v, %q, wanted %v, %q", tt.senders, m, reason, tt.want, tt.reason)
 		}
 	}
 }
@@ -170,3 +186,129 @@ func makeRequestWithBody(body []byte, eventType, repo, action string) (*http.Req
 	r.Header.Add(pullRequestRepoHeader, repo)
 	return r, body
 }
+
+func TestMatchEvent(t *testing.T) {
+	matchTests := []struct {
+		repo   string
+		action string
+		want   bool
+		reason decision.Reason
+	}{
+		{testFullname, "open", true, decision.Matched},
+		{testFullname, "update", true, decision.Matched},
+		{testFullname, "close", false, decision.ActionNotInList},
+		{"testing/other", "open", false, decision.RepoMismatch},
+	}
+
+	for _, tt := range matchTests {
+		r, _ := makeRequestWithBody([]byte(`{}`), "Merge Request Hook", testFullname, "open,update")
+		m, reason, err := MatchEvent(r, &event.Event{Type: event.PullRequest, Repo: tt.repo, Action: tt.action})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != tt.want || reason != tt.reason {
+			t.Errorf("MatchEvent(%q, %q) got %v, %q, wanted %v, %q", tt.repo, tt.action, m, reason, tt.want, tt.reason)
+		}
+	}
+}
+
+func TestMatchEventWithMultipleRepos(t *testing.T) {
+	r, _ := makeRequestWithBody([]byte(`{}`), "pull_request", "testing/other,"+testFullname, "open")
+
+	if m, _, _ := MatchEvent(r, &event.Event{Type: event.PullRequest, Repo: testFullname, Action: "open"}); !m {
+		t.Fatal("MatchEvent() got false, wanted true")
+	}
+}
+
+func TestMatchEventWithRefs(t *testing.T) {
+	refTests := []struct {
+		name     string
+		baseRefs string
+		headRefs string
+		want     bool
+		reason   decision.Reason
+	}{
+		{"no refs", "", "", true, decision.Matched},
+		{"matching base ref", "main,glob:release/*", "", true, decision.Matched},
+		{"other base ref", "glob:release/*", "", false, decision.BaseRefNotIncluded},
+		{"matching head ref", "", "glob:feature/*", true, decision.Matched},
+		{"other head ref", "main", "glob:fix/*", false, decision.HeadRefNotIncluded},
+	}
+
+	for _, tt := range refTests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := makeRequestWithBody([]byte(`{}`), "pull_request", testFullname, "open")
+			r.Header.Set(pullRequestBaseRefHeader, tt.baseRefs)
+			r.Header.Set(pullRequestHeadRefHeader, tt.headRefs)
+			hook := &event.Event{Type: event.PullRequest, Repo: testFullname, Action: "open", BaseRef: "main", HeadRef: "feature/login"}
+
+			m, reason, err := MatchEvent(r, hook)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m != tt.want || reason != tt.reason {
+				t.Fatalf("MatchEvent() got %v, %q, wanted %v, %q", m, reason, tt.want, tt.reason)
+			}
+		})
+	}
+}
+
+func TestMatchPullRequestActionWithHeaders(t *testing.T) {
+	labelTests := []struct {
+		name    string
+		body    string
+		headers map[string]string
+		want    bool
+		reason  decision.Reason
+	}{
+		{"draft", `{"action":"opened","pull_request":{"draft":true}}`, nil, false, decision.DraftPullRequest},
+		{"draft included", `{"action":"opened","pull_request":{"draft":true}}`,
+			map[string]string{pullRequestIncludeDraftsHeader: "true"}, true, decision.Matched},
+		{"ready for review", `{"action":"ready_for_review","pull_request":{"draft":false}}`, nil, true, decision.Matched},
+		{"labelled", `{"action":"opened","pull_request":{"labels":[{"name":"run-e2e"}]}}`,
+			map[string]string{pullRequestLabelsHeader: "run-e2e"}, true, decision.Matched},
+		{"not labelled", `{"action":"opened","pull_request":{"labels":[{"name":"bug"}]}}`,
+			map[string]string{pullRequestLabelsHeader: "run-e2e"}, false, decision.LabelNotIncluded},
+		{"excluded label", `{"action":"opened","pull_request":{"labels":[{"name":"run-e2e"},{"name":"hold"}]}}`,
+			map[string]string{pullRequestLabelsHeader: "run-e2e", pullRequestExcludeLabelsHeader: "hold"}, false, decision.LabelExcluded},
+		{"label added", `{"action":"labeled","label":{"name":"run-e2e"},"pull_request":{"labels":[{"name":"run-e2e"}]}}`,
+			map[string]string{pullRequestLabelsHeader: "run-e2e"}, true, decision.Matched},
+		{"other label added", `{"action":"labeled","label":{"name":"bug"},"pull_request":{"labels":[{"name":"run-e2e"},{"name":"bug"}]}}`,
+			map[string]string{pullRequestLabelsHeader: "run-e2e"}, false, decision.LabelChangeIgnored},
+		{"skip marker in title", `{"action":"opened","pull_request":{"title":"[skip ci] Update the docs"}}`, nil, false, decision.SkipMarker},
+		{"skip marker in body", `{"action":"opened","pull_request":{"body":"Docs only [ci skip]"}}`, nil, false, decision.SkipMarker},
+		{"skip disabled", `{"action":"opened","pull_request":{"title":"[skip ci] Update the docs"}}`,
+			map[string]string{skip.DisabledHeader: "true"}, true, decision.Matched},
+	}
+
+	for _, tt := range labelTests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := sjson.Set(tt.body, "repository.full_name", testFullname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, _ := makeRequestWithBody([]byte(body), "pull_request", testFullname, "opened,labeled,ready_for_review")
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			m, reason, err := MatchPullRequestAction(r, []byte(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m != tt.want || reason != tt.reason {
+				t.Fatalf("MatchPullRequestAction() got %v, %q, wanted %v, %q", m, reason, tt.want, tt.reason)
+			}
+		})
+	}
+}
+
+func TestMatchEventWithInvalidRef(t *testing.T) {
+	r, _ := makeRequestWithBody([]byte(`{}`), "pull_request", testFullname, "open")
+	r.Header.Set(pullRequestBaseRefHeader, "regex:(")
+
+	_, _, err := MatchEvent(r, &event.Event{Type: event.PullRequest, Repo: testFullname, Action: "open"})
+	if err == nil {
+		t.Fatal("expected an error with an invalid pattern")
+	}
+}
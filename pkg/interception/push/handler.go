@@ -6,20 +6,24 @@ import (
 	"net/http"
 
 	"github.com/google/go-github/v28/github"
-	"github.com/tidwall/sjson"
+	"go.uber.org/zap"
 
+	"github.com/bigkevmcd/interceptor/pkg/decision"
 	"github.com/bigkevmcd/interceptor/pkg/git"
+	"github.com/bigkevmcd/interceptor/pkg/logging"
+	"github.com/bigkevmcd/interceptor/pkg/naming"
+	"github.com/bigkevmcd/interceptor/pkg/skip"
 )
 
-// Handler is an InterceptionFunc that checks that the GitHub request
-// body matches the requested fields.
+// Intercept is an Interceptor that checks that the GitHub request body
+// matches the requested fields.
 //
 // It recognises the following request headers:
 //    X-GitHub-Event - this is provided by GitHub in its hook-mechanism
 //    Push-Ref - this is configured on the trigger interceptor
 //    PushExclude-Ref - this is configured on the trigger interceptor
 //    Push-Repo - this is the full name of the GitHub repo e.g.
-//    tektoncd/triggers.
+//    tektoncd/triggers, or a comma-separated list of repos.
 //
 // If a Push-Repo is provided, and no Push-Ref, then this will match on _all_
 // pushes from the Repo.
@@ -28,33 +32,91 @@ import (
 // provided, then the interceptor will match only if the hook's ref does not
 // match the excluded ref.
 //
+// Push-Ref and PushExclude-Ref can be comma-separated lists of exact names,
+// "glob:" or "regex:" patterns, excluded refs take precedence.
+//
+// If a Push-Tag or Push-Tag-Constraint is provided, only tag pushes match,
+// Push-Tag is a list of patterns that the tag must match, and
+// Push-Tag-Constraint is a semantic version range e.g. ">=1.0.0 <2.0.0",
+// prerelease versions are excluded unless Push-Tag-Prerelease is "true".
+//
+// If Push-Paths or Push-Exclude-Paths are provided, these are comma-separated
+// lists of globs that are matched against the files changed in the commits
+// in the push, and the push only matches if files remain after filtering.
+//
 // If the request matches the configuration, the body is returned, with an
 // additional key added to the body: "intercepted.ref" which will be the
-// shortened version of the ref extracting just the last part (the branch).
-func Handler(r *http.Request, body []byte) ([]byte, error) {
+// shortened version of the ref extracting just the last part (the branch),
+// along with "intercepted.short_sha" and "intercepted.fullname".
+//
+// For tag pushes "intercepted.tag" is added, and if the tag is a semantic
+// version, "intercepted.version" with the major, minor, patch and prerelease
+// parts.
+//
+// If paths are being matched, the matching files are added as
+// "intercepted.changed_files".
+//
+// Pushes with a skip marker like "[skip ci]" in the head commit message are
+// rejected, see skip.FromRequest for the headers that configure this.
+//
+// Pushes that delete a ref are rejected unless Push-On-Delete is "true",
+// and pushes that create a ref or are force pushes are matched unless
+// Push-On-Create or Push-On-Force are "false", the "intercepted.created",
+// "intercepted.deleted" and "intercepted.forced" flags, and the
+// "intercepted.before" and "intercepted.after" SHAs are added to the body.
+func Intercept(r *http.Request, body []byte) (*decision.Decision, error) {
 	var event github.PushEvent
 	err := json.Unmarshal(body, &event)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal request body: %w", err)
 	}
 
-	match, err := MatchPushAction(r, &event)
+	opts := skip.FromRequest(r)
+	files, match, reason, err := matchPush(r, &event, opts)
 	if err != nil {
 		return nil, fmt.Errorf("error matching push: %w", err)
 	}
+	e := Event(&event)
+	logging.Decision(r.Context(), reason, opts.DecisionFields(e, reason,
+		zap.String("repo", e.Repo), zap.String("ref", event.GetRef()))...)
 	if !match {
-		return nil, nil
+		return decision.Denied(reason), nil
 	}
 
-	intercepted := map[string]interface{}{
-		"ref":       refToBranch(event.Ref),
-		"short_sha": git.ShortenSHA(strValue(event.HeadCommit.ID)),
+	intercepted := InterceptedValues(&event)
+	if files != nil {
+		intercepted["changed_files"] = files
 	}
-	updatedBody, err := sjson.SetBytes(body, "intercepted", intercepted)
+	return decision.AllowedEvent(e, intercepted), nil
+}
+
+// Handler is an InterceptionFunc that returns the body with the intercepted
+// values from Intercept, or nil if the push doesn't match.
+func Handler(r *http.Request, body []byte) ([]byte, error) {
+	d, err := Intercept(r, body)
 	if err != nil {
-		return nil, fmt.Errorf("error setting the intercepted values: %w", err)
+		return nil, err
+	}
+	return d.Apply(body)
+}
+
+// InterceptedValues returns the values that are added to the body of a
+// matching push as "intercepted", including the Kubernetes-safe names from
+// naming.Values.
+//
+// If the push has no head commit, e.g. when a ref is deleted, the short_sha
+// is taken from the "after" SHA.
+func InterceptedValues(event *github.PushEvent) map[string]interface{} {
+	e := Event(event)
+	intercepted := map[string]interface{}{
+		"ref":       git.BranchName(event.GetRef()),
+		"short_sha": git.ShortenSHA(e.SHA),
+		"fullname":  e.Repo,
 	}
-	return updatedBody, nil
+	AddTagValues(intercepted, event.GetRef())
+	AddRefChangeValues(intercepted, e)
+	naming.AddValues(intercepted, e)
+	return intercepted
 }
 
 func max(x, y int) int {
@@ -38,6 +38,10 @@ func TestHandleWithSuccess(t *testing.T) {
 	if shortSHA.Value() != "abc123" {
 		t.Errorf("intercepted.commit got %s, wanted %s", shortSHA, "abc123")
 	}
+	fullName := gjson.GetBytes(newBody, "intercepted.fullname")
+	if fullName.Value() != "testing/testing" {
+		t.Errorf("intercepted.fullname got %s, wanted %s", fullName, "testing/testing")
+	}
 
 	// Delete the addition to simplify the return comparison.
 	returnBody, err := sjson.DeleteBytes(newBody, "intercepted")
@@ -100,3 +104,109 @@ func mustMarshal(t *testing.T, e interface{}) []byte {
 	}
 	return body
 }
+
+func TestHandleWithTagPush(t *testing.T) {
+	event := &github.PushEvent{
+		Ref: github.String("refs/tags/v1.2.3"),
+		Repo: &github.PushEventRepository{
+			FullName: github.String("testing/testing"),
+		},
+		HeadCommit: &github.PushEventCommit{
+			ID: github.String("abc123456789"),
+		},
+	}
+	r := makeRequest(t, event, "push", "", "")
+	r.Header.Add(pushTagConstraintHeader, ">=1.0.0 <2.0.0")
+	body := mustMarshal(t, event)
+
+	newBody, err := Handler(r, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := map[string]interface{}{
+		"tag":                "v1.2.3",
+		"version.major":      float64(1),
+		"version.minor":      float64(2),
+		"version.patch":      float64(3),
+		"version.prerelease": "",
+	}
+	for k, v := range wanted {
+		if got := gjson.GetBytes(newBody, "intercepted."+k).Value(); got != v {
+			t.Errorf("intercepted.%s got %#v, wanted %#v", k, got, v)
+		}
+	}
+}
+
+func TestHandleWithPaths(t *testing.T) {
+	event := &github.PushEvent{
+		Ref: github.String("refs/heads/master"),
+		Repo: &github.PushEventRepository{
+			FullName: github.String("testing/testing"),
+		},
+		HeadCommit: &github.PushEventCommit{
+			ID: github.String("abc123456789"),
+		},
+		Commits: []github.PushEventCommit{
+			{Modified: []string{"services/api/main.go", "docs/README.md"}},
+		},
+	}
+	r := makeRequest(t, event, "push", "master", "")
+	r.Header.Add(pushPathsHeader, "services/**")
+	body := mustMarshal(t, event)
+
+	newBody, err := Handler(r, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := gjson.GetBytes(newBody, "intercepted.changed_files").Value()
+	want := []interface{}{"services/api/main.go"}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("intercepted.changed_files got %#v, wanted %#v", files, want)
+	}
+}
+
+func TestHandleWithDeletedBranch(t *testing.T) {
+	event := &github.PushEvent{
+		Ref: github.String("refs/heads/my-branch"),
+		Repo: &github.PushEventRepository{
+			FullName: github.String("testing/testing"),
+		},
+		Before:  github.String("6a6bcddc365ca3a38c9055a603c9590a7fae7ca6"),
+		After:   github.String("0000000000000000000000000000000000000000"),
+		Deleted: github.Bool(true),
+	}
+	body := mustMarshal(t, event)
+	r := makeRequest(t, event, "push", "", "")
+
+	newBody, err := Handler(r, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newBody != nil {
+		t.Fatalf("Handler() got %s, wanted nil", newBody)
+	}
+
+	r.Header.Set(pushOnDeleteHeader, "true")
+	newBody, err = Handler(r, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"ref":         "my-branch",
+		"branch_name": "my-branch",
+		"repo_name":   "testing-testing",
+		"short_sha":   "000000",
+		"created":     false,
+		"deleted":     true,
+		"forced":      false,
+		"before":      "6a6bcddc365ca3a38c9055a603c9590a7fae7ca6",
+		"after":       "0000000000000000000000000000000000000000",
+	}
+	for k, v := range want {
+		if got := gjson.GetBytes(newBody, "intercepted."+k).Value(); got != v {
+			t.Errorf("intercepted.%s got %#v, wanted %#v", k, got, v)
+		}
+	}
+}
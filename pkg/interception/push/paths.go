@@ -0,0 +1,72 @@
+package push
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/google/go-github/v28/github"
+
+	"github.com/bigkevmcd/interceptor/pkg/pattern"
+)
+
+const (
+	pushPathsHeader        = "Push-Paths"
+	pushExcludePathsHeader = "Push-Exclude-Paths"
+)
+
+// MatchPaths matches the files changed by the commits in a push against the
+// Push-Paths and Push-Exclude-Paths headers.
+//
+// Both headers are comma-separated lists of glob patterns, files that match
+// an excluded pattern are ignored, and if there are include patterns, files
+// must match one of them.
+//
+// If neither header is provided, this always matches, and the files are nil,
+// otherwise it matches if any files are left, and these are returned.
+func MatchPaths(r *http.Request, event *github.PushEvent) ([]string, bool, error) {
+	includeHeader := r.Header.Get(pushPathsHeader)
+	excludeHeader := r.Header.Get(pushExcludePathsHeader)
+	if includeHeader == "" && excludeHeader == "" {
+		return nil, true, nil
+	}
+	include, err := pattern.ParseGlobList(includeHeader)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %s: %w", pushPathsHeader, err)
+	}
+	exclude, err := pattern.ParseGlobList(excludeHeader)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %s: %w", pushExcludePathsHeader, err)
+	}
+
+	matched := []string{}
+	for _, f := range changedFiles(event) {
+		if exclude.Match(f) {
+			continue
+		}
+		if !include.Empty() && !include.Match(f) {
+			continue
+		}
+		matched = append(matched, f)
+	}
+	return matched, len(matched) > 0, nil
+}
+
+// changedFiles returns the sorted, unique files added, modified or removed
+// in all the commits in the push.
+func changedFiles(event *github.PushEvent) []string {
+	seen := map[string]bool{}
+	for _, c := range event.Commits {
+		for _, files := range [][]string{c.Added, c.Modified, c.Removed} {
+			for _, f := range files {
+				seen[f] = true
+			}
+		}
+	}
+	files := []string{}
+	for f := range seen {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
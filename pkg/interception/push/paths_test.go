@@ -0,0 +1,90 @@
+package push
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+)
+
+func TestMatchPaths(t *testing.T) {
+	event := &github.PushEvent{
+		Commits: []github.PushEventCommit{
+			{
+				Added:    []string{"docs/README.md"},
+				Modified: []string{"pkg/git/utils.go"},
+			},
+			{
+				Modified: []string{"pkg/git/utils.go", "services/api/main.go"},
+				Removed:  []string{"services/web/index.html"},
+			},
+		},
+	}
+
+	pathTests := []struct {
+		name      string
+		include   string
+		exclude   string
+		wantFiles []string
+		wantMatch bool
+	}{
+		{"no headers", "", "", nil, true},
+		{"matching path", "services/api/**", "", []string{"services/api/main.go"}, true},
+		{"removed file", "services/web/**", "", []string{"services/web/index.html"}, true},
+		{"multiple paths", "services/**, docs/**", "", []string{"docs/README.md", "services/api/main.go", "services/web/index.html"}, true},
+		{"no matching paths", "services/db/**", "", []string{}, false},
+		{"excluded paths", "", "docs/**,**/*.go", []string{"services/web/index.html"}, true},
+		{"all excluded", "", "**", []string{}, false},
+		{"exclude takes precedence", "services/**", "services/web/**", []string{"services/api/main.go"}, true},
+	}
+
+	for _, tt := range pathTests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := makeRequestWithBody([]byte(`{}`), "push", testFullname, "", "")
+			if tt.include != "" {
+				r.Header.Add(pushPathsHeader, tt.include)
+			}
+			if tt.exclude != "" {
+				r.Header.Add(pushExcludePathsHeader, tt.exclude)
+			}
+
+			files, match, err := MatchPaths(r, event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match != tt.wantMatch {
+				t.Errorf("MatchPaths() got match %v, wanted %v", match, tt.wantMatch)
+			}
+			if !reflect.DeepEqual(files, tt.wantFiles) {
+				t.Errorf("MatchPaths() got files %#v, wanted %#v", files, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestMatchPathsWithInvalidPattern(t *testing.T) {
+	r := makeRequestWithBody([]byte(`{}`), "push", testFullname, "", "")
+	r.Header.Add(pushPathsHeader, "regex:(")
+
+	_, _, err := MatchPaths(r, &github.PushEvent{})
+	if err == nil {
+		t.Fatal("expected an error with an invalid pattern")
+	}
+}
+
+func TestMatchPushActionWithUnmatchedPaths(t *testing.T) {
+	event := makeHookBody("refs/heads/master")
+	event.Commits = []github.PushEventCommit{{Modified: []string{"docs/README.md"}}}
+	r := makeRequest(t, event, "push", "master", "")
+	r.Header.Add(pushPathsHeader, "services/**")
+
+	matched, reason, err := MatchPushAction(r, event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matched || reason != decision.PathsNotMatched {
+		t.Fatalf("MatchPushAction() got %v, %q, wanted false, %q", matched, reason, decision.PathsNotMatched)
+	}
+}
@@ -1,11 +1,18 @@
 package push
 
 import (
-	"log"
+	"fmt"
 	"net/http"
-	"regexp"
 
 	"github.com/google/go-github/v28/github"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/git"
+	"github.com/bigkevmcd/interceptor/pkg/headers"
+	"github.com/bigkevmcd/interceptor/pkg/matcher"
+	"github.com/bigkevmcd/interceptor/pkg/pattern"
+	"github.com/bigkevmcd/interceptor/pkg/skip"
 )
 
 const (
@@ -14,89 +21,165 @@ const (
 	pushRefHeader        = "Push-Ref"
 	pushExcludeRefHeader = "PushExclude-Ref"
 	pushRepoHeader       = "Push-Repo"
+	pushSenderHeader     = "Push-Sender"
+	pushOnCreateHeader   = "Push-On-Create"
+	pushOnDeleteHeader   = "Push-On-Delete"
+	pushOnForceHeader    = "Push-On-Force"
 )
 
-var branchRE = regexp.MustCompile("^refs/heads/")
-
 // MatchPushAction will match on push notifications, if the ref for the
 // commit matches the branch provided in the pushRefHeader and the Push-Repo
 // matches the repository.full_name in the body.
-func MatchPushAction(r *http.Request, event *github.PushEvent) (bool, error) {
+//
+// The Push-Ref and PushExclude-Ref headers can be comma-separated lists of
+// patterns, see pattern.Parse for the syntax.
+//
+// If the Push-Paths or Push-Exclude-Paths headers are provided, the files
+// changed in the push must also match, see MatchPaths.
+//
+// The reason for the decision is returned along with the match.
+func MatchPushAction(r *http.Request, event *github.PushEvent) (bool, decision.Reason, error) {
+	_, match, reason, err := matchPush(r, event, skip.FromRequest(r))
+	return match, reason, err
+}
+
+// matchPush is MatchPushAction, and also returns the files that matched the
+// Push-Paths and Push-Exclude-Paths headers, see MatchPaths.
+func matchPush(r *http.Request, event *github.PushEvent, opts *skip.Options) ([]string, bool, decision.Reason, error) {
 	if !isPushEvent(r) {
-		log.Println("debug: dropping request because not a push event")
-		return false, nil
+		return nil, false, decision.EventMismatch, nil
 	}
 
-	hookPush := pushFromHook(r, event)
-	requestPush := pushFromRequest(r)
-	log.Printf("debug: hookPush = %v, requestPush = %s", hookPush, requestPush)
-
-	return requestMatchesHook(requestPush, hookPush), nil
+	match, reason, err := MatchEventWithSkipOptions(r, Event(event), opts)
+	if err != nil || !match {
+		return nil, false, reason, err
+	}
+	files, match, err := MatchPaths(r, event)
+	if err != nil {
+		return nil, false, "", err
+	}
+	if !match {
+		return nil, false, decision.PathsNotMatched, nil
+	}
+	return files, true, decision.Matched, nil
 }
 
-func isPushEvent(r *http.Request) bool {
-	return r.Header.Get(gitHubEventHeader) == pushEventType
+// MatchEvent matches the Push-Repo, Push-Ref, PushExclude-Ref and
+// Push-Sender headers in the request against a push event.
+//
+// Pushes that delete a ref only match if Push-On-Delete is "true", pushes
+// that create a ref or force push don't match if Push-On-Create or
+// Push-On-Force are "false".
+//
+// If either the Push-Tag or Push-Tag-Constraint headers are provided, then
+// only tag pushes are matched, against the tag patterns and semantic version
+// constraint, Push-Ref is ignored for these, but the full ref of the tag
+// e.g. "refs/tags/v0.1.0" must not match the PushExclude-Ref.
+func MatchEvent(r *http.Request, e *event.Event) (bool, decision.Reason, error) {
+	return MatchEventWithSkipOptions(r, e, skip.FromRequest(r))
 }
 
-// exclude is only set from incoming request headers in the pushFromRequest
-// function, it's used to indicate a branch that should _not_ be allowed to
-// match.
-type push struct {
-	repoName string
-	ref      string
-	exclude  string
+// MatchEventWithSkipOptions is MatchEvent with the skip options that were
+// already parsed from the request, so that the same options can log the
+// decision, see skip.Options.DecisionFields.
+func MatchEventWithSkipOptions(r *http.Request, e *event.Event, opts *skip.Options) (bool, decision.Reason, error) {
+	m, err := requestMatcher(r, opts)
+	if err != nil {
+		return false, "", err
+	}
+	match, reason := m.Match(e)
+	return match, reason, nil
 }
 
-func pushFromHook(r *http.Request, event *github.PushEvent) *push {
-	return &push{repoName: repoName(event), ref: refToBranch(event.Ref)}
+// Event returns the normalised event for a GitHub push.
+//
+// Gitea and Forgejo send GitHub-like pushes without the "created" and
+// "deleted" flags, so a zero "before" or "after" SHA also marks a push as
+// creating or deleting the ref.
+func Event(e *github.PushEvent) *event.Event {
+	return &event.Event{
+		Type:   event.Push,
+		Repo:   e.GetRepo().GetFullName(),
+		Ref:    git.BranchName(e.GetRef()),
+		Sender: e.GetSender().GetLogin(),
+		SHA:    headSHA(e),
+
+		HeadCommitMessage: e.GetHeadCommit().GetMessage(),
+		CommitMessages:    commitMessages(e),
+		Before:            e.GetBefore(),
+		After:             e.GetAfter(),
+		Created:           e.GetCreated() || git.IsZeroSHA(e.GetBefore()),
+		Deleted:           e.GetDeleted() || git.IsZeroSHA(e.GetAfter()),
+		Forced:            e.GetForced(),
+	}
 }
 
-func pushFromRequest(r *http.Request) *push {
-	ref := r.Header.Get(pushRefHeader)
-	exclude := r.Header.Get(pushExcludeRefHeader)
-	repo := r.Header.Get(pushRepoHeader)
-
-	return &push{repoName: repo, ref: ref, exclude: exclude}
+// AddRefChangeValues adds the "created", "deleted" and "forced" flags, and
+// the "before" and "after" SHAs for a push to the intercepted values.
+func AddRefChangeValues(intercepted map[string]interface{}, e *event.Event) {
+	intercepted["created"] = e.Created
+	intercepted["deleted"] = e.Deleted
+	intercepted["forced"] = e.Forced
+	intercepted["before"] = e.Before
+	intercepted["after"] = e.After
 }
 
-func repoName(e *github.PushEvent) string {
-	if e.Repo == nil {
-		return ""
+// headSHA returns the SHA of the head commit of a push, if the push has no
+// head commit, e.g. when a ref is deleted, this is the "after" SHA.
+func headSHA(e *github.PushEvent) string {
+	if sha := e.GetHeadCommit().GetID(); sha != "" {
+		return sha
 	}
-	return strValue(e.Repo.FullName)
+	return e.GetAfter()
 }
 
-func strValue(s *string) string {
-	if s == nil {
-		return ""
+func commitMessages(e *github.PushEvent) []string {
+	var messages []string
+	for _, c := range e.Commits {
+		messages = append(messages, c.GetMessage())
 	}
-	return *s
+	return messages
 }
 
-func refToBranch(s *string) string {
-	return branchRE.ReplaceAllString(strValue(s), "")
-}
-
-func (p push) Equal(o push) bool {
-	return p.repoName == o.repoName &&
-		p.ref == o.ref &&
-		p.exclude == o.exclude
+func isPushEvent(r *http.Request) bool {
+	return r.Header.Get(gitHubEventHeader) == pushEventType
 }
 
-// If the request (from the headers) push matches the hook push (in the body)
-// then this is true.
+// requestMatcher returns a matcher for the headers in the request.
 //
-// If the requested branch is empty, then return true if the repoName matches.
-// This allows for matching on _all_ branches in a repo.
-func requestMatchesHook(reqPush, hookPush *push) bool {
-	if reqPush.Equal(*hookPush) {
-		return true
+// The repo must match one of the requested repos exactly, and the ref must
+// match one of the requested ref patterns, and none of the excluded ref
+// patterns, excluded refs take precedence.
+//
+// If the requested ref is empty, then all refs that are not excluded match,
+// this allows for matching on _all_ branches in a repo.
+//
+// Pushes with a skip marker in the head commit message don't match, see
+// skip.FromRequest.
+func requestMatcher(r *http.Request, opts *skip.Options) (matcher.Matcher, error) {
+	repo := matcher.Repo(headers.SplitList(r.Header.Get(pushRepoHeader)))
+	sender := matcher.Sender(headers.SplitList(r.Header.Get(pushSenderHeader)))
+	skipped := opts.Matcher()
+	changes := matcher.All(
+		matcher.Deleted(r.Header.Get(pushOnDeleteHeader) == "true"),
+		matcher.Created(r.Header.Get(pushOnCreateHeader) != "false"),
+		matcher.Forced(r.Header.Get(pushOnForceHeader) != "false"),
+	)
+	exclude, err := pattern.ParseList(r.Header.Get(pushExcludeRefHeader))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", pushExcludeRefHeader, err)
 	}
-	if reqPush.ref == "" && reqPush.exclude == "" {
-		return reqPush.repoName == hookPush.repoName
+	if tagRequested(r) {
+		tag, err := tagMatcher(r)
+		if err != nil {
+			return nil, err
+		}
+		return matcher.All(repo, matcher.Ref(nil, exclude), tag, changes, sender, skipped), nil
 	}
-	if reqPush.ref == "" && reqPush.exclude != hookPush.ref {
-		return true
+
+	include, err := pattern.ParseList(r.Header.Get(pushRefHeader))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", pushRefHeader, err)
 	}
-	return false
+	return matcher.All(repo, matcher.Ref(include, exclude), changes, sender, skipped), nil
 }
@@ -4,9 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"net/http"
+	"reflect"
 	"testing"
 
 	"github.com/google/go-github/v28/github"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/skip"
 )
 
 const (
@@ -17,13 +22,13 @@ func TestMatchPushActionWithMatchingAction(t *testing.T) {
 	event := makeHookBody("refs/heads/master")
 	r := makeRequest(t, event, "push", "master", "")
 
-	matched, err := MatchPushAction(r, event)
+	matched, reason, err := MatchPushAction(r, event)
 
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !matched {
-		t.Fatal("MatchPushAction() got false, wanted true")
+	if !matched || reason != decision.Matched {
+		t.Fatalf("MatchPushAction() got %v, %q, wanted true, %q", matched, reason, decision.Matched)
 	}
 }
 
@@ -31,13 +36,13 @@ func TestMatchPushActionWithNoHeaderAndExcludedRef(t *testing.T) {
 	event := makeHookBody("refs/heads/master")
 	r := makeRequest(t, event, "push", "", "master")
 
-	matched, err := MatchPushAction(r, event)
+	matched, reason, err := MatchPushAction(r, event)
 
 	if err != nil {
 		t.Fatal(err)
 	}
-	if matched {
-		t.Fatal("MatchPushAction() got true, wanted false")
+	if matched || reason != decision.RefExcluded {
+		t.Fatalf("MatchPushAction() got %v, %q, wanted false, %q", matched, reason, decision.RefExcluded)
 	}
 }
 
@@ -45,81 +50,49 @@ func TestMatchPushActionWithUnmatchedBranch(t *testing.T) {
 	event := makeHookBody("refs/heads/my-branch")
 	r := makeRequest(t, event, "push", "master", "")
 
-	matched, err := MatchPushAction(r, event)
+	matched, reason, err := MatchPushAction(r, event)
 
 	if err != nil {
 		t.Fatal(err)
 	}
-	if matched {
-		t.Fatalf("MatchPushAction() got true, wanted false")
+	if matched || reason != decision.RefNotIncluded {
+		t.Fatalf("MatchPushAction() got %v, %q, wanted false, %q", matched, reason, decision.RefNotIncluded)
 	}
 }
 
-func TestPushFromHook(t *testing.T) {
-	keyTests := []struct {
-		event    string
-		hookBody *github.PushEvent
-		p        push
-	}{
-		{
-			"push", &github.PushEvent{
-				Ref: github.String("refs/heads/my-branch"),
-				Repo: &github.PushEventRepository{
-					FullName: github.String(testFullname),
-				},
-			}, push{"testing/testing", "my-branch", ""},
+func TestEvent(t *testing.T) {
+	hook := &github.PushEvent{
+		Ref: github.String("refs/heads/my-branch"),
+		Repo: &github.PushEventRepository{
+			FullName: github.String(testFullname),
+		},
+		Sender:     &github.User{Login: github.String("octocat")},
+		Before:     github.String("0000000000000000000000000000000000000000"),
+		After:      github.String("6a6bcddc365ca3a38c9055a603c9590a7fae7ca6"),
+		Created:    github.Bool(true),
+		Forced:     github.Bool(true),
+		HeadCommit: &github.PushEventCommit{Message: github.String("Fix the tests")},
+		Commits: []github.PushEventCommit{
+			{Message: github.String("Add the tests")},
+			{Message: github.String("Fix the tests")},
 		},
 	}
-
-	for _, tt := range keyTests {
-		k := pushFromHook(makeRequest(t, tt.hookBody, tt.event, "open", ""), tt.hookBody)
-		if !k.Equal(tt.p) {
-			t.Errorf("pushFromHook() got %v, wanted %v", k, tt.p)
-		}
-	}
-}
-
-func TestPushFromRequest(t *testing.T) {
-	keyTests := []struct {
-		eventType string
-		repo      string
-		ref       string
-		exclude   string
-		p         push
-	}{
-		{"push", "test/test", "master", "", push{"test/test", "master", ""}},
-		{"push", "test/project", "branch1", "", push{"test/project", "branch1", ""}},
-		{"push", "test/project", "branch1", "exclude", push{"test/project", "branch1", "exclude"}},
-	}
-
-	for _, tt := range keyTests {
-		r, _ := http.NewRequest("POST", "/testing", bytes.NewReader([]byte(``)))
-		r.Header.Add("Content-Type", "application/json")
-		r.Header.Add(gitHubEventHeader, tt.eventType)
-		r.Header.Add(pushRepoHeader, tt.repo)
-		r.Header.Add(pushRefHeader, tt.ref)
-		r.Header.Add(pushExcludeRefHeader, tt.exclude)
-
-		k := pushFromRequest(r)
-		if !k.Equal(tt.p) {
-			t.Errorf("pushFromRequest() got %v, wanted %v", k, tt.p)
-		}
-	}
-}
-
-func TestRefToBranch(t *testing.T) {
-	refTests := []struct {
-		ref    string
-		branch string
-	}{
-		{"refs/heads/master", "master"},
-		{"refs/heads/my-branch", "my-branch"},
+	want := &event.Event{
+		Type:              event.Push,
+		Repo:              testFullname,
+		Ref:               "my-branch",
+		Sender:            "octocat",
+		SHA:               "6a6bcddc365ca3a38c9055a603c9590a7fae7ca6",
+		HeadCommitMessage: "Fix the tests",
+		CommitMessages:    []string{"Add the tests", "Fix the tests"},
+		Before:            "0000000000000000000000000000000000000000",
+		After:             "6a6bcddc365ca3a38c9055a603c9590a7fae7ca6",
+		Created:           true,
+		Forced:            true,
 	}
 
-	for _, tt := range refTests {
-		if b := refToBranch(github.String(tt.ref)); b != tt.branch {
-			t.Errorf("refToBranch(%s) got %s, wanted %s", tt.ref, b, tt.branch)
-		}
+	if e := Event(hook); !reflect.DeepEqual(e, want) {
+		t.Fatalf("Event() got %#v, wanted %#v", e, want)
 	}
 }
 
@@ -154,3 +127,205 @@ func makeRequestWithBody(body []byte, eventType, repo, ref, exclude string) *htt
 	r.Header.Add(pushRepoHeader, repo)
 	return r
 }
+
+func TestMatchEventWithRepoAndRef(t *testing.T) {
+	matchTests := []struct {
+		repo   string
+		ref    string
+		want   bool
+		reason decision.Reason
+	}{
+		{testFullname, "master", true, decision.Matched},
+		{testFullname, "my-branch", false, decision.RefNotIncluded},
+		{"testing/other", "master", false, decision.RepoMismatch},
+	}
+
+	for _, tt := range matchTests {
+		r := makeRequestWithBody([]byte(`{}`), "Push Hook", testFullname, "master", "")
+		m, reason, err := MatchEvent(r, &event.Event{Type: event.Push, Repo: tt.repo, Ref: tt.ref})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != tt.want || reason != tt.reason {
+			t.Errorf("MatchEvent(%q, %q) got %v, %q, wanted %v, %q", tt.repo, tt.ref, m, reason, tt.want, tt.reason)
+		}
+	}
+}
+
+func TestMatchEvent(t *testing.T) {
+	matchTests := []struct {
+		name    string
+		ref     string
+		exclude string
+		hookRef string
+		want    bool
+		reason  decision.Reason
+	}{
+		{"exact ref", "master", "", "master", true, decision.Matched},
+		{"different ref", "master", "", "my-branch", false, decision.RefNotIncluded},
+		{"no ref", "", "", "my-branch", true, decision.Matched},
+		{"no ref and excluded ref", "", "master", "master", false, decision.RefExcluded},
+		{"no ref and other excluded ref", "", "master", "my-branch", true, decision.Matched},
+		{"multiple refs", "master, develop", "", "develop", true, decision.Matched},
+		{"glob ref", "glob:release/*", "", "release/v1.0", true, decision.Matched},
+		{"glob ref no match", "glob:release/*", "", "releases/v1.0", false, decision.RefNotIncluded},
+		{"regex ref", "regex:^release/v[0-9]+$", "", "release/v12", true, decision.Matched},
+		{"glob exclude", "", "glob:dependabot/**", "dependabot/npm/lodash", false, decision.RefExcluded},
+		{"exclude takes precedence", "glob:release/*", "release/broken", "release/broken", false, decision.RefExcluded},
+		{"include with exclude", "glob:release/*", "release/broken", "release/v1.0", true, decision.Matched},
+		{"multiple excludes", "", "master,glob:dependabot/**", "dependabot/go", false, decision.RefExcluded},
+	}
+
+	for _, tt := range matchTests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := makeRequestWithBody([]byte(`{}`), "push", testFullname, tt.ref, tt.exclude)
+			hook := &event.Event{Type: event.Push, Repo: testFullname, Ref: tt.hookRef}
+
+			m, reason, err := MatchEvent(r, hook)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m != tt.want || reason != tt.reason {
+				t.Fatalf("MatchEvent() got %v, %q, wanted %v, %q", m, reason, tt.want, tt.reason)
+			}
+		})
+	}
+}
+
+func TestMatchEventWithMultipleRepos(t *testing.T) {
+	r := makeRequestWithBody([]byte(`{}`), "push", "testing/other, "+testFullname, "", "")
+	hook := &event.Event{Type: event.Push, Repo: testFullname, Ref: "master"}
+
+	m, _, err := MatchEvent(r, hook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m {
+		t.Fatal("MatchEvent() got false, wanted true")
+	}
+}
+
+func TestMatchEventWithDifferentRepo(t *testing.T) {
+	for _, exclude := range []string{"", "other"} {
+		r := makeRequestWithBody([]byte(`{}`), "push", "testing/other", "", exclude)
+		hook := &event.Event{Type: event.Push, Repo: testFullname, Ref: "master"}
+
+		m, reason, err := MatchEvent(r, hook)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m || reason != decision.RepoMismatch {
+			t.Errorf("MatchEvent() with exclude %q got %v, %q, wanted false, %q", exclude, m, reason, decision.RepoMismatch)
+		}
+	}
+}
+
+func TestMatchEventWithSenders(t *testing.T) {
+	senderTests := []struct {
+		senders string
+		want    bool
+		reason  decision.Reason
+	}{
+		{"", true, decision.Matched},
+		{"octocat, hubot", true, decision.Matched},
+		{"dependabot[bot]", false, decision.SenderNotAllowed},
+	}
+
+	for _, tt := range senderTests {
+		r := makeRequestWithBody([]byte(`{}`), "push", testFullname, "", "")
+		r.Header.Set(pushSenderHeader, tt.senders)
+		hook := &event.Event{Type: event.Push, Repo: testFullname, Ref: "master", Sender: "octocat"}
+
+		m, reason, err := MatchEvent(r, hook)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != tt.want || reason != tt.reason {
+			t.Errorf("MatchEvent() with senders %q got %v, %q, wanted %v, %q", tt.senders, m, reason, tt.want, tt.reason)
+		}
+	}
+}
+
+func TestMatchPushActionWithInvalidPattern(t *testing.T) {
+	event := makeHookBody("refs/heads/master")
+	r := makeRequest(t, event, "push", "regex:(", "")
+
+	_, _, err := MatchPushAction(r, event)
+	if err == nil {
+		t.Fatal("expected an error with an invalid pattern")
+	}
+}
+
+func TestMatchPushActionWithSkipMarker(t *testing.T) {
+	skipTests := []struct {
+		name    string
+		message string
+		headers map[string]string
+		want    bool
+		reason  decision.Reason
+	}{
+		{"no marker", "Fix the tests", nil, true, decision.Matched},
+		{"skip ci", "Update the docs [skip ci]", nil, false, decision.SkipMarker},
+		{"disabled", "Update the docs [skip ci]", map[string]string{skip.DisabledHeader: "true"}, true, decision.Matched},
+	}
+
+	for _, tt := range skipTests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := makeHookBody("refs/heads/master")
+			hook.HeadCommit = &github.PushEventCommit{Message: github.String(tt.message)}
+			r := makeRequest(t, hook, "push", "master", "")
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			matched, reason, err := MatchPushAction(r, hook)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if matched != tt.want || reason != tt.reason {
+				t.Fatalf("MatchPushAction() got %v, %q, wanted %v, %q", matched, reason, tt.want, tt.reason)
+			}
+		})
+	}
+}
+
+func TestMatchPushActionWithRefChanges(t *testing.T) {
+	changeTests := []struct {
+		name    string
+		hook    *github.PushEvent
+		headers map[string]string
+		want    bool
+		reason  decision.Reason
+	}{
+		{"update", &github.PushEvent{}, nil, true, decision.Matched},
+		{"deleted", &github.PushEvent{Deleted: github.Bool(true)}, nil, false, decision.RefDeleted},
+		{"deleted included", &github.PushEvent{Deleted: github.Bool(true)},
+			map[string]string{pushOnDeleteHeader: "true"}, true, decision.Matched},
+		{"created", &github.PushEvent{Created: github.Bool(true)}, nil, true, decision.Matched},
+		{"created excluded", &github.PushEvent{Created: github.Bool(true)},
+			map[string]string{pushOnCreateHeader: "false"}, false, decision.RefCreated},
+		{"forced", &github.PushEvent{Forced: github.Bool(true)}, nil, true, decision.Matched},
+		{"forced excluded", &github.PushEvent{Forced: github.Bool(true)},
+			map[string]string{pushOnForceHeader: "false"}, false, decision.ForcedPush},
+	}
+
+	for _, tt := range changeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := tt.hook
+			hook.Ref = github.String("refs/heads/master")
+			hook.Repo = &github.PushEventRepository{FullName: github.String(testFullname)}
+			r := makeRequest(t, hook, "push", "master", "")
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			matched, reason, err := MatchPushAction(r, hook)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if matched != tt.want || reason != tt.reason {
+				t.Fatalf("MatchPushAction() got %v, %q, wanted %v, %q", matched, reason, tt.want, tt.reason)
+			}
+		})
+	}
+}
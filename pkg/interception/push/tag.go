@@ -0,0 +1,91 @@
+package push
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/git"
+	"github.com/bigkevmcd/interceptor/pkg/matcher"
+	"github.com/bigkevmcd/interceptor/pkg/pattern"
+)
+
+const (
+	pushTagHeader           = "Push-Tag"
+	pushTagConstraintHeader = "Push-Tag-Constraint"
+	pushTagPrereleaseHeader = "Push-Tag-Prerelease"
+)
+
+// tagRequested returns true if the request is configured to match tag
+// pushes.
+func tagRequested(r *http.Request) bool {
+	return r.Header.Get(pushTagHeader) != "" || r.Header.Get(pushTagConstraintHeader) != ""
+}
+
+// tagMatcher returns a matcher for tag pushes that matches the Push-Tag
+// patterns and the Push-Tag-Constraint semantic version range.
+//
+// Tags that are not valid semantic versions never match a constraint, and
+// prerelease versions only match if Push-Tag-Prerelease is "true", or the
+// constraint includes a prerelease, they're compared with the constraint
+// including the prerelease, so v2.0.0-rc.1 doesn't match ">=2.0.0".
+func tagMatcher(r *http.Request) (matcher.Matcher, error) {
+	tags, err := pattern.ParseList(r.Header.Get(pushTagHeader))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", pushTagHeader, err)
+	}
+	var c *semver.Constraints
+	if constraint := strings.TrimSpace(r.Header.Get(pushTagConstraintHeader)); constraint != "" {
+		c, err = semver.NewConstraint(constraint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", pushTagConstraintHeader, err)
+		}
+		c.IncludePrerelease = r.Header.Get(pushTagPrereleaseHeader) == "true"
+	}
+
+	return matcher.Func(func(e *event.Event) (bool, decision.Reason) {
+		tag, ok := git.TagName(e.Ref)
+		if !ok {
+			return false, decision.NotTag
+		}
+		if !tags.Empty() && !tags.Match(tag) {
+			return false, decision.TagNotIncluded
+		}
+		if c == nil {
+			return true, decision.Matched
+		}
+		v, err := semver.NewVersion(tag)
+		if err != nil {
+			return false, decision.NotSemver
+		}
+		if !c.Check(v) {
+			return false, decision.VersionOutOfRange
+		}
+		return true, decision.Matched
+	}), nil
+}
+
+// AddTagValues adds "tag" and if the tag is a semantic version, "version"
+// with the major, minor, patch and prerelease parts of the version, to the
+// intercepted values, if the ref is a tag.
+func AddTagValues(intercepted map[string]interface{}, ref string) {
+	tag, ok := git.TagName(ref)
+	if !ok {
+		return
+	}
+	intercepted["tag"] = tag
+	v, err := semver.NewVersion(tag)
+	if err != nil {
+		return
+	}
+	intercepted["version"] = map[string]interface{}{
+		"major":      v.Major(),
+		"minor":      v.Minor(),
+		"patch":      v.Patch(),
+		"prerelease": v.Prerelease(),
+	}
+}
@@ -0,0 +1,111 @@
+package push
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/git"
+)
+
+func TestMatchEventWithTags(t *testing.T) {
+	tagTests := []struct {
+		name    string
+		headers map[string]string
+		ref     string
+		want    bool
+		reason  decision.Reason
+	}{
+		{"any tag", map[string]string{pushTagHeader: "glob:*"}, "refs/tags/v1.2.3", true, decision.Matched},
+		{"branch push", map[string]string{pushTagHeader: "glob:*"}, "refs/heads/master", false, decision.NotTag},
+		{"tag pattern", map[string]string{pushTagHeader: "glob:v1.*"}, "refs/tags/v2.0.0", false, decision.TagNotIncluded},
+		{"in range", map[string]string{pushTagConstraintHeader: ">=1.0.0 <2.0.0"}, "refs/tags/v1.2.3", true, decision.Matched},
+		{"out of range", map[string]string{pushTagConstraintHeader: ">=1.0.0 <2.0.0"}, "refs/tags/v2.0.0", false, decision.VersionOutOfRange},
+		{"not a version", map[string]string{pushTagConstraintHeader: ">=1.0.0"}, "refs/tags/latest", false, decision.NotSemver},
+		{"prerelease excluded", map[string]string{pushTagConstraintHeader: ">=1.0.0 <2.0.0"}, "refs/tags/v1.2.3-rc.1", false, decision.VersionOutOfRange},
+		{"prerelease included", map[string]string{
+			pushTagConstraintHeader: ">=1.0.0 <2.0.0",
+			pushTagPrereleaseHeader: "true",
+		}, "refs/tags/v1.2.3-rc.1", true, decision.Matched},
+		{"prerelease before the lower bound", map[string]string{
+			pushTagConstraintHeader: ">=2.0.0",
+			pushTagPrereleaseHeader: "true",
+		}, "refs/tags/v2.0.0-rc.1", false, decision.VersionOutOfRange},
+		{"prerelease after the lower bound", map[string]string{
+			pushTagConstraintHeader: ">=2.0.0",
+			pushTagPrereleaseHeader: "true",
+		}, "refs/tags/v2.0.1-rc.1", true, decision.Matched},
+		{"pattern and range", map[string]string{
+			pushTagHeader:           "glob:v*",
+			pushTagConstraintHeader: "^1.2",
+		}, "refs/tags/v1.4.0", true, decision.Matched},
+	}
+
+	for _, tt := range tagTests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := makeRequestWithBody([]byte(`{}`), "push", testFullname, "", "")
+			for k, v := range tt.headers {
+				r.Header.Add(k, v)
+			}
+
+			m, reason, err := MatchEvent(r, &event.Event{Type: event.Push, Repo: testFullname, Ref: git.BranchName(tt.ref)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m != tt.want || reason != tt.reason {
+				t.Fatalf("MatchEvent() got %v, %q, wanted %v, %q", m, reason, tt.want, tt.reason)
+			}
+		})
+	}
+}
+
+func TestMatchEventWithTagInOtherRepo(t *testing.T) {
+	r := makeRequestWithBody([]byte(`{}`), "push", testFullname, "", "")
+	r.Header.Add(pushTagHeader, "glob:*")
+
+	m, reason, err := MatchEvent(r, &event.Event{Type: event.Push, Repo: "testing/other", Ref: "refs/tags/v1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m || reason != decision.RepoMismatch {
+		t.Fatalf("MatchEvent() got %v, %q, wanted false, %q", m, reason, decision.RepoMismatch)
+	}
+}
+
+func TestMatchEventWithInvalidConstraint(t *testing.T) {
+	r := makeRequestWithBody([]byte(`{}`), "push", testFullname, "", "")
+	r.Header.Add(pushTagConstraintHeader, ">=banana")
+
+	_, _, err := MatchEvent(r, &event.Event{Type: event.Push, Repo: testFullname, Ref: "refs/tags/v1.0.0"})
+	if err == nil {
+		t.Fatal("expected an error with an invalid constraint")
+	}
+}
+
+func TestAddTagValues(t *testing.T) {
+	valueTests := []struct {
+		ref  string
+		want map[string]interface{}
+	}{
+		{"refs/heads/master", map[string]interface{}{}},
+		{"refs/tags/latest", map[string]interface{}{"tag": "latest"}},
+		{"refs/tags/v1.2.3-rc.1", map[string]interface{}{
+			"tag": "v1.2.3-rc.1",
+			"version": map[string]interface{}{
+				"major":      uint64(1),
+				"minor":      uint64(2),
+				"patch":      uint64(3),
+				"prerelease": "rc.1",
+			},
+		}},
+	}
+
+	for _, tt := range valueTests {
+		intercepted := map[string]interface{}{}
+		AddTagValues(intercepted, tt.ref)
+		if !reflect.DeepEqual(intercepted, tt.want) {
+			t.Errorf("AddTagValues(%q) got %#v, wanted %#v", tt.ref, intercepted, tt.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package interception
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// responseBuffer is an http.ResponseWriter that buffers the response from a
+// handler, so that it can be changed before it's written to the client.
+type responseBuffer struct {
+	code        int
+	wroteHeader bool
+	header      http.Header
+	body        bytes.Buffer
+}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{code: http.StatusOK, header: http.Header{}}
+}
+
+// Header implements the http.ResponseWriter interface.
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+// Write implements the http.ResponseWriter interface.
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
+
+// WriteHeader implements the http.ResponseWriter interface, as with
+// http.ResponseWriter, only the first status code is kept.
+func (b *responseBuffer) WriteHeader(code int) {
+	if b.wroteHeader {
+		return
+	}
+	b.code = code
+	b.wroteHeader = true
+}
@@ -0,0 +1,45 @@
+package interception
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestResponseBuffer(t *testing.T) {
+	bufferTests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		wantCode int
+		wantBody string
+	}{
+		{"no status", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("testing"))
+		}, http.StatusOK, "testing"},
+		{"error", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "failed", http.StatusBadRequest)
+		}, http.StatusBadRequest, "failed\n"},
+		{"status after write", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("testing"))
+			w.WriteHeader(http.StatusInternalServerError)
+		}, http.StatusOK, "testing"},
+		{"second status", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.WriteHeader(http.StatusInternalServerError)
+		}, http.StatusPreconditionFailed, ""},
+	}
+
+	for _, tt := range bufferTests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newResponseBuffer()
+
+			tt.handler(b, nil)
+
+			if b.code != tt.wantCode {
+				t.Errorf("got code %d, wanted %d", b.code, tt.wantCode)
+			}
+			if got := b.body.String(); got != tt.wantBody {
+				t.Errorf("got body %q, wanted %q", got, tt.wantBody)
+			}
+		})
+	}
+}
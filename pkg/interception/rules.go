@@ -0,0 +1,178 @@
+package interception
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/sjson"
+	"go.uber.org/zap"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/interception/bitbucket"
+	"github.com/bigkevmcd/interceptor/pkg/interception/gitlab"
+	"github.com/bigkevmcd/interceptor/pkg/logging"
+	"github.com/bigkevmcd/interceptor/pkg/rules"
+)
+
+const (
+	// RulesPath is the path that rules are served from, rules are addressed
+	// as /rules/<name>.
+	RulesPath = "/rules/"
+
+	// RulesVersionHeader is the response header that identifies the version
+	// of the rules that handled the request.
+	RulesVersionHeader = "X-Interceptor-Rules-Version"
+)
+
+// configHeaderPrefixes are the prefixes of the headers that configure the
+// handlers, these are removed from requests for rules, so that only the
+// rule's configuration is used.
+var configHeaderPrefixes = []string{"Push-", "Pushexclude-", "Pullrequest-", "Interceptor-"}
+
+// eventKinds maps the event-types for each provider to the rule event that
+// they match.
+var eventKinds = makeEventKinds()
+
+func makeEventKinds() map[string]string {
+	m := map[string]string{
+		"push":                    rules.PushEvent,
+		"pull_request":            rules.PullRequestEvent,
+		gitlab.PushEvent:          rules.PushEvent,
+		gitlab.TagPushEvent:       rules.PushEvent,
+		gitlab.MergeRequestEvent:  rules.PullRequestEvent,
+		bitbucket.CloudPushEvent:  rules.PushEvent,
+		bitbucket.ServerPushEvent: rules.PushEvent,
+	}
+	for _, k := range bitbucket.CloudPullRequestEvents {
+		m[k] = rules.PullRequestEvent
+	}
+	for _, k := range bitbucket.ServerPullRequestEvents {
+		m[k] = rules.PullRequestEvent
+	}
+	return m
+}
+
+// RulesHandler wraps a handler, and configures it with the rule named in
+// the URL path, rather than with headers on the trigger.
+//
+// The rule's conditions are converted to the equivalent headers, and the
+// request is passed to the wrapped handler, any Push-*, PushExclude-*,
+// Pullrequest-* and Interceptor-* headers in the request are removed, so
+// that the rule is the only configuration.
+//
+// Hooks for events that don't match the rule's event are rejected, and if
+// the wrapped handler allows the hook, the rule's enrichment values are
+// added to "intercepted", or to the rule's outputKey if it has one.
+//
+// The rules are fetched from the source once per request, so requests are
+// completed with the same rules, even if the source is reloaded, and the
+// version of the rules is returned in the X-Interceptor-Rules-Version header.
+//
+// A GET request to /rules/ returns the active version and rule names, and
+// if the source reports that the last reload failed, the "lastError".
+func RulesHandler(src rules.Source, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rs := src.RuleSet()
+		w.Header().Set(RulesVersionHeader, rs.Version())
+		name := strings.TrimPrefix(r.URL.Path, RulesPath)
+		if name == "" && r.Method == http.MethodGet {
+			writeRulesStatus(w, src, rs)
+			return
+		}
+		rule := rs.Get(name)
+		if rule == nil {
+			http.Error(w, fmt.Sprintf("unknown rule %q", name), http.StatusNotFound)
+			return
+		}
+
+		_, eventType := hookEvent(r)
+		if kind := eventKinds[eventType]; kind != rule.Event {
+			fields := append(requestFields(r), zap.String("rule", rule.Name))
+			logging.Decision(r.Context(), decision.EventMismatch, fields...)
+			writeDenied(w, decision.Denied(decision.EventMismatch))
+			return
+		}
+
+		ruleReq := r.Clone(logging.WithFields(r.Context(), zap.String("rule", rule.Name)))
+		removeConfigHeaders(ruleReq.Header)
+		for k, v := range rule.Headers() {
+			ruleReq.Header[k] = v
+		}
+		buf := newResponseBuffer()
+		next(buf, ruleReq)
+
+		body := buf.body.Bytes()
+		if buf.code == http.StatusOK {
+			enriched, err := enrich(body, ruleOutputKey(rule), rule.Enrichment)
+			if err != nil {
+				msg := fmt.Sprintf("failed handling the event: %s", err.Error())
+				http.Error(w, msg, http.StatusInternalServerError)
+				return
+			}
+			body = enriched
+		}
+		for k, v := range buf.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(buf.code)
+		w.Write(body)
+	}
+}
+
+// removeConfigHeaders removes the headers that configure the handlers.
+func removeConfigHeaders(h http.Header) {
+	for k := range h {
+		for _, prefix := range configHeaderPrefixes {
+			if strings.HasPrefix(http.CanonicalHeaderKey(k), prefix) {
+				delete(h, k)
+				break
+			}
+		}
+	}
+}
+
+// errorSource is a rules.Source that can report that the last reload of the
+// rules failed, e.g. a rules.Watcher.
+type errorSource interface {
+	LastError() error
+}
+
+func writeRulesStatus(w http.ResponseWriter, src rules.Source, rs *rules.RuleSet) {
+	status := map[string]interface{}{
+		"version": rs.Version(),
+		"rules":   rs.Names(),
+	}
+	if es, ok := src.(errorSource); ok {
+		if err := es.LastError(); err != nil {
+			status["lastError"] = err.Error()
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		logging.Default().Error("failed to write the rules status", zap.Error(err))
+	}
+}
+
+// ruleOutputKey returns the key that the intercepted values for the rule are
+// added to the body as.
+func ruleOutputKey(rule *rules.Rule) string {
+	if rule.OutputKey != "" {
+		return rule.OutputKey
+	}
+	return decision.DefaultKey
+}
+
+// enrich adds the values to the intercepted values, which are the key in the
+// body.
+func enrich(body []byte, key string, values map[string]string) ([]byte, error) {
+	for k, v := range values {
+		updated, err := sjson.SetBytes(body, key+"."+k, v)
+		if err != nil {
+			return nil, fmt.Errorf("error setting the enrichment value %s: %w", k, err)
+		}
+		body = updated
+	}
+	return body, nil
+}
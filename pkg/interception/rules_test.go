@@ -0,0 +1,339 @@
+package interception
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/bigkevmcd/interceptor/pkg/interception/bitbucket"
+	"github.com/bigkevmcd/interceptor/pkg/interception/gitea"
+	"github.com/bigkevmcd/interceptor/pkg/interception/gitlab"
+	"github.com/bigkevmcd/interceptor/pkg/rules"
+	"github.com/bigkevmcd/interceptor/pkg/skip"
+)
+
+const testRulesConfig = `
+rules:
+  - name: dev-ci
+    event: push
+    repos: [testing/testing]
+    refs: [master]
+    enrichment:
+      environment: dev
+`
+
+func TestRulesHandlerConfiguresHandler(t *testing.T) {
+	var hookHeaders http.Header
+	h := RulesHandler(mustParseRules(t), func(w http.ResponseWriter, r *http.Request) {
+		hookHeaders = r.Header
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	})
+	r := makeRulesRequest("/rules/dev-ci", gitHubEventHeader, "push")
+	r.Header.Set("Push-Ref", "my-branch")
+	r.Header.Set("Push-On-Delete", "true")
+	r.Header.Set("PushExclude-Ref", "master")
+	r.Header.Set(skip.DisabledHeader, "true")
+	r.Header.Set(FilterHeader, "false")
+	r.Header.Set("X-Request-Id", "testing")
+	w := httptest.NewRecorder()
+
+	h(w, r)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code, got %d, wanted %d", resp.StatusCode, http.StatusOK)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type incorrect, got %s, wanted %s", ct, "application/json")
+	}
+	if v := resp.Header.Get(RulesVersionHeader); v != mustParseRules(t).Version() {
+		t.Errorf("%s got %q, wanted %q", RulesVersionHeader, v, mustParseRules(t).Version())
+	}
+	if v := hookHeaders.Get("Push-Ref"); v != "master" {
+		t.Errorf("Push-Ref got %q, wanted %q", v, "master")
+	}
+	if v := hookHeaders.Get("Push-Repo"); v != "testing/testing" {
+		t.Errorf("Push-Repo got %q, wanted %q", v, "testing/testing")
+	}
+	for _, k := range []string{"Push-On-Delete", "PushExclude-Ref", skip.DisabledHeader, FilterHeader} {
+		if v, ok := hookHeaders[http.CanonicalHeaderKey(k)]; ok {
+			t.Errorf("%s got %q, wanted it to be removed", k, v)
+		}
+	}
+	if v := hookHeaders.Get("X-Request-Id"); v != "testing" {
+		t.Errorf("X-Request-Id got %q, wanted %q", v, "testing")
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := gjson.GetBytes(body, "intercepted.environment").String(); v != "dev" {
+		t.Errorf("intercepted.environment got %q, wanted %q", v, "dev")
+	}
+}
+
+func TestRulesHandlerWithGitLabPush(t *testing.T) {
+	called := false
+	h := RulesHandler(mustParseRules(t), func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	r := makeRulesRequest("/rules/dev-ci", gitlab.EventHeader, gitlab.PushEvent)
+	w := httptest.NewRecorder()
+
+	h(w, r)
+
+	if !called {
+		t.Fatal("wrapped handler not called for a GitLab push")
+	}
+}
+
+func TestRulesHandlerWithUnsupportedForkPolicy(t *testing.T) {
+	rs, err := rules.Parse([]byte(`
+rules:
+  - name: forks
+    event: pull_request
+    repos: [testing/testing]
+    actions: [opened]
+    forkPolicy: reject
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := RulesHandler(rs, DefaultServer().ServeHTTP)
+	providerTests := []struct {
+		provider    string
+		eventHeader string
+		eventType   string
+	}{
+		{GitLabProvider, gitlab.EventHeader, gitlab.MergeRequestEvent},
+		{BitbucketProvider, bitbucket.EventHeader, "pullrequest:created"},
+		{GiteaProvider, gitea.EventHeader, gitea.PullRequestEvent},
+	}
+
+	for _, tt := range providerTests {
+		t.Run(tt.provider, func(t *testing.T) {
+			r := makeRulesRequest("/rules/forks", tt.eventHeader, tt.eventType)
+			w := httptest.NewRecorder()
+
+			h(w, r)
+
+			if w.Code != http.StatusInternalServerError {
+				t.Fatalf("unexpected status code, got %d, wanted %d", w.Code, http.StatusInternalServerError)
+			}
+			want := `fork policy "reject" is not supported for provider ` + tt.provider
+			if b := w.Body.String(); !strings.Contains(b, want) {
+				t.Fatalf("got body %q, wanted it to contain %q", b, want)
+			}
+		})
+	}
+}
+
+func TestRulesHandlerWithSkipOptions(t *testing.T) {
+	rs, err := rules.Parse([]byte(`
+rules:
+  - name: markers
+    event: push
+    repos: [testing/testing]
+    skipMarkers: ["[skip dev]"]
+    skipAllCommits: true
+  - name: disabled
+    event: push
+    repos: [testing/testing]
+    skipDisabled: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := RulesHandler(rs, DefaultServer().ServeHTTP)
+	body := []byte(`{"ref":"refs/heads/master","repository":{"full_name":"testing/testing"},` +
+		`"head_commit":{"id":"abc123456789","message":"[skip ci] Update the docs"},` +
+		`"commits":[{"id":"def456789012","message":"[skip dev] Fix typo"},{"id":"abc123456789","message":"[skip ci] Update the docs"}]}`)
+	skipTests := []struct {
+		rule       string
+		wantStatus int
+		wantReason string
+	}{
+		{"markers", http.StatusPreconditionFailed, "skip_marker"},
+		{"disabled", http.StatusOK, "matched"},
+	}
+
+	for _, tt := range skipTests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/rules/"+tt.rule, bytes.NewReader(body))
+			r.Header.Add(gitHubEventHeader, "push")
+			w := httptest.NewRecorder()
+
+			h(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("unexpected status code, got %d, wanted %d", w.Code, tt.wantStatus)
+			}
+			if reason := w.Header().Get(ReasonHeader); reason != tt.wantReason {
+				t.Fatalf("got reason %q, wanted %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestRulesHandlerWithOutputKeyAndEnrichment(t *testing.T) {
+	rs, err := rules.Parse([]byte(`
+rules:
+  - name: dev-ci
+    event: push
+    repos: [testing/testing]
+    templates:
+      image_tag: "{{ .ShortSHA }}"
+    outputKey: ci
+    enrichment:
+      environment: dev
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := RulesHandler(rs, DefaultServer().ServeHTTP)
+	body := []byte(`{"ref":"refs/heads/master","repository":{"full_name":"testing/testing"},"head_commit":{"id":"abc123456789"}}`)
+	r := httptest.NewRequest("POST", "/rules/dev-ci", bytes.NewReader(body))
+	r.Header.Add(gitHubEventHeader, "push")
+	w := httptest.NewRecorder()
+
+	h(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code, got %d, wanted %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	newBody := w.Body.Bytes()
+	if intercepted := gjson.GetBytes(newBody, "intercepted"); intercepted.Exists() {
+		t.Errorf("got intercepted %s, wanted it to be moved to ci", intercepted)
+	}
+	for k, want := range map[string]string{"environment": "dev", "image_tag": "abc123", "ref": "master"} {
+		if v := gjson.GetBytes(newBody, "ci."+k).String(); v != want {
+			t.Errorf("ci.%s got %q, wanted %q", k, v, want)
+		}
+	}
+}
+
+func TestRulesHandlerRejections(t *testing.T) {
+	rejectionTests := []struct {
+		name       string
+		path       string
+		eventType  string
+		wantStatus int
+	}{
+		{"unknown rule", "/rules/unknown", "push", http.StatusNotFound},
+		{"different event", "/rules/dev-ci", "pull_request", http.StatusPreconditionFailed},
+		{"unknown event", "/rules/dev-ci", "ping", http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range rejectionTests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := RulesHandler(mustParseRules(t), func(w http.ResponseWriter, r *http.Request) {
+				t.Fatal("wrapped handler called")
+			})
+			r := makeRulesRequest(tt.path, gitHubEventHeader, tt.eventType)
+			w := httptest.NewRecorder()
+
+			h(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("unexpected status code, got %d, wanted %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRulesHandlerWithFailedInterception(t *testing.T) {
+	h := RulesHandler(mustParseRules(t), func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "failed interception", http.StatusPreconditionFailed)
+	})
+	r := makeRulesRequest("/rules/dev-ci", gitHubEventHeader, "push")
+	w := httptest.NewRecorder()
+
+	h(w, r)
+
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("unexpected status code, got %d, wanted %d", w.Code, http.StatusPreconditionFailed)
+	}
+	if b := w.Body.String(); b != "failed interception\n" {
+		t.Fatalf("unexpected body, got %q", b)
+	}
+}
+
+func mustParseRules(t *testing.T) *rules.RuleSet {
+	t.Helper()
+	rs, err := rules.Parse([]byte(testRulesConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rs
+}
+
+func makeRulesRequest(path, eventHeader, eventType string) *http.Request {
+	r := httptest.NewRequest("POST", path, bytes.NewReader([]byte(`{"ref":"refs/heads/master"}`)))
+	r.Header.Add("Content-Type", "application/json")
+	r.Header.Add(eventHeader, eventType)
+	return r
+}
+
+func TestRulesHandlerStatus(t *testing.T) {
+	rs := mustParseRules(t)
+	h := RulesHandler(rs, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("wrapped handler called")
+	})
+	r := httptest.NewRequest("GET", RulesPath, nil)
+	w := httptest.NewRecorder()
+
+	h(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code, got %d, wanted %d", w.Code, http.StatusOK)
+	}
+	var status struct {
+		Version string   `json:"version"`
+		Rules   []string `json:"rules"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != rs.Version() {
+		t.Errorf("version got %q, wanted %q", status.Version, rs.Version())
+	}
+	if !reflect.DeepEqual(status.Rules, []string{"dev-ci"}) {
+		t.Errorf("rules got %#v, wanted %#v", status.Rules, []string{"dev-ci"})
+	}
+}
+
+type failedSource struct {
+	rs *rules.RuleSet
+}
+
+func (s failedSource) RuleSet() *rules.RuleSet {
+	return s.rs
+}
+
+func (failedSource) LastError() error {
+	return errors.New("invalid rules file")
+}
+
+func TestRulesHandlerStatusWithLastError(t *testing.T) {
+	h := RulesHandler(failedSource{mustParseRules(t)}, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("wrapped handler called")
+	})
+	r := httptest.NewRequest("GET", RulesPath, nil)
+	w := httptest.NewRecorder()
+
+	h(w, r)
+
+	if v := gjson.GetBytes(w.Body.Bytes(), "lastError").String(); v != "invalid rules file" {
+		t.Fatalf("lastError got %q, wanted %q", v, "invalid rules file")
+	}
+}
@@ -0,0 +1,240 @@
+package interception
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/expression"
+	"github.com/bigkevmcd/interceptor/pkg/git"
+	"github.com/bigkevmcd/interceptor/pkg/logging"
+	"github.com/bigkevmcd/interceptor/pkg/metrics"
+	"github.com/bigkevmcd/interceptor/pkg/secrets"
+	"github.com/bigkevmcd/interceptor/pkg/templates"
+)
+
+// Server is an http.Handler that passes hooks to the Interceptor registered
+// for the provider and event-type of the hook.
+//
+// The event-type is extracted from the GitHub, GitLab, Bitbucket or Gitea
+// hook event header.
+//
+// If there's no Interceptor for the event-type, the body is returned with a
+// successful response, allowing unknown events through.
+//
+// The reason for the decision is returned in the X-Interceptor-Reason
+// header, and rejected hooks get an HTTP 412 response (unless the decision
+// has a different status), with the reason in the body.
+//
+// If an Interceptor allows a hook, the CEL expressions in the
+// Interceptor-Filter and Interceptor-Overlay headers are evaluated against
+// the hook, the hook is rejected if the filter returns false, and the
+// results of the overlays are added to "intercepted".
+//
+// The templates in the Interceptor-Template headers are then rendered and
+// added to "intercepted", and the intercepted values are added to the body
+// as the Interceptor-Output-Key if it's provided.
+//
+// The logger in the request context has the delivery ID, provider and event
+// added, and Interceptors log their decisions with it.
+type Server struct {
+	mu           sync.RWMutex
+	interceptors map[string]map[string]Interceptor
+	metrics      *metrics.Metrics
+	secrets      secrets.Getter
+	expressions  *expression.Evaluator
+	templates    *templates.Renderer
+	shaLength    int
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithMetrics records the outcome of each hook in the metrics.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(s *Server) {
+		s.metrics = m
+	}
+}
+
+// WithSecrets verifies the signatures of hooks with the secrets before they
+// are intercepted, see VerifySignatures.
+func WithSecrets(g secrets.Getter) Option {
+	return func(s *Server) {
+		s.secrets = g
+	}
+}
+
+// WithShortSHALength shortens the SHAs in the "short_sha" intercepted value,
+// and the ShortSHA in templates, to n characters instead of
+// git.DefaultAbbrevLength, n must be valid, see git.CheckAbbrevLength.
+func WithShortSHALength(n int) Option {
+	return func(s *Server) {
+		s.shaLength = n
+	}
+}
+
+// NewServer creates a Server with no Interceptors registered.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		interceptors: map[string]map[string]Interceptor{},
+		expressions:  expression.MustNew(),
+		templates:    templates.New(),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// Register sets the Interceptor for hooks of the event-type from the
+// provider, replacing any existing Interceptor.
+func (s *Server) Register(provider, eventType string, i Interceptor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.interceptors[provider] == nil {
+		s.interceptors[provider] = map[string]Interceptor{}
+	}
+	s.interceptors[provider][eventType] = i
+}
+
+// unknownEventType is the event_type recorded in the metrics for hooks with
+// no registered Interceptor.
+const unknownEventType = "unknown"
+
+// ServeHTTP implements http.Handler.
+//
+// Hooks that fail signature verification are recorded in the metrics with
+// the "rejected" outcome.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.secrets != nil {
+		start := time.Now()
+		verifySignatures(s.secrets, s.handle, func(r *http.Request) {
+			provider, eventType := s.eventLabels(r)
+			s.metrics.Hook(provider, eventType, "", metrics.Rejected, time.Since(start))
+		})(w, r)
+		return
+	}
+	s.handle(w, r)
+}
+
+// eventLabels returns the provider and event-type for the metrics for a
+// hook.
+//
+// The event-type comes from the request, so to keep the number of label
+// values bounded, it's recorded as "unknown" if there's no Interceptor
+// registered for it.
+func (s *Server) eventLabels(r *http.Request) (string, string) {
+	provider, eventType := hookEvent(r)
+	if _, ok := s.Lookup(provider, eventType); !ok {
+		return provider, unknownEventType
+	}
+	return provider, eventType
+}
+
+// recordHook records the outcome of a hook for a repo in the metrics.
+//
+// The repo comes from the hook body, so to keep the number of label values
+// bounded, it's only recorded if the signature of the hook was verified, or
+// if the hook matched, and so the repo is one that a trigger is configured
+// for, otherwise it's recorded as "".
+func (s *Server) recordHook(provider, eventType, repo, outcome string, start time.Time) {
+	if s.secrets == nil && outcome != metrics.Matched {
+		repo = ""
+	}
+	s.metrics.Hook(provider, eventType, repo, outcome, time.Since(start))
+}
+
+// Lookup returns the Interceptor registered for the provider and event-type
+// if there is one.
+func (s *Server) Lookup(provider, eventType string) (Interceptor, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i, ok := s.interceptors[provider][eventType]
+	return i, ok
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	provider, eventType := hookEvent(r)
+	h, ok := s.Lookup(provider, eventType)
+	if !ok {
+		eventType = unknownEventType
+	}
+	r = r.WithContext(logging.WithFields(r.Context(), requestFields(r)...))
+	logger := logging.FromContext(r.Context())
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		msg := fmt.Sprintf("failed to read the request body: %s", err.Error())
+		http.Error(w, msg, http.StatusBadRequest)
+		s.metrics.Hook(provider, eventType, "", metrics.Error, time.Since(start))
+		return
+	}
+	s.metrics.BodySize(provider, eventType, len(body))
+	repo := hookRepoName(r, body)
+	if !ok {
+		logger.Info("no handler for event, passing through", zap.String("repo", repo))
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.Write(body)
+		s.metrics.Hook(provider, eventType, "", metrics.Passthrough, time.Since(start))
+		return
+	}
+
+	logger.Debug("handling event", zap.String("repo", repo))
+	funcStart := time.Now()
+	d, err := h.Intercept(r, body)
+	s.metrics.Func(provider, eventType, time.Since(funcStart))
+	if err == nil && d.Allow {
+		shortenSHA(d, s.shaLength)
+		d, err = applyExpressions(s.expressions, r, body, d)
+		if err == nil && !d.Allow {
+			logging.Decision(r.Context(), d.Reason, zap.String("repo", repo))
+		}
+	}
+	if err == nil && d.Allow {
+		d, err = applyTemplates(s.templates, r, body, d, s.shaLength)
+	}
+	if err != nil {
+		logger.Error("failed handling the event", zap.String("repo", repo), zap.Error(err))
+		msg := fmt.Sprintf("failed handling the event: %s", err.Error())
+		http.Error(w, msg, http.StatusInternalServerError)
+		s.recordHook(provider, eventType, repo, metrics.Error, start)
+		return
+	}
+
+	if !d.Allow {
+		writeDenied(w, d)
+		s.recordHook(provider, eventType, repo, metrics.NotMatched, start)
+		return
+	}
+	newBody, err := d.Apply(body)
+	if err != nil {
+		msg := fmt.Sprintf("failed handling the event: %s", err.Error())
+		http.Error(w, msg, http.StatusInternalServerError)
+		s.recordHook(provider, eventType, repo, metrics.Error, start)
+		return
+	}
+	w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+	w.Header().Set(ReasonHeader, string(d.Reason))
+	if d.Status != 0 {
+		w.WriteHeader(d.Status)
+	}
+	w.Write(newBody)
+	s.recordHook(provider, eventType, repo, metrics.Matched, start)
+}
+
+// shortenSHA replaces the "short_sha" intercepted value with the SHA of the
+// event shortened to n characters, if n is set.
+func shortenSHA(d *decision.Decision, n int) {
+	if n == 0 || d.Event == nil {
+		return
+	}
+	if _, ok := d.Intercepted["short_sha"]; ok {
+		d.Intercepted["short_sha"] = git.AbbreviateSHA(d.Event.SHA, n)
+	}
+}
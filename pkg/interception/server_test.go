@@ -0,0 +1,499 @@
+package interception
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/git"
+	"github.com/bigkevmcd/interceptor/pkg/interception/gitea"
+	"github.com/bigkevmcd/interceptor/pkg/interception/gitlab"
+	"github.com/bigkevmcd/interceptor/pkg/interception/pullrequest"
+	"github.com/bigkevmcd/interceptor/pkg/logging"
+	"github.com/bigkevmcd/interceptor/pkg/metrics"
+	"github.com/bigkevmcd/interceptor/pkg/secrets"
+	"github.com/bigkevmcd/interceptor/pkg/signature"
+)
+
+func TestServerWithUnknownEventTypeReturnsBody(t *testing.T) {
+	s := NewServer()
+	s.Register(GitHubProvider, "push", FromInterceptionFunc(func(r *http.Request, body []byte) ([]byte, error) {
+		t.Fatal("push handler called")
+		return nil, nil
+	}))
+	testBody := []byte(`{}`)
+	r, _ := http.NewRequest("POST", "/", bytes.NewReader(testBody))
+	r.Header.Add("Content-Type", "application/json")
+	r.Header.Add(gitHubEventHeader, "unknown")
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, r)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status code, got %d, wanted %d", resp.StatusCode, http.StatusOK)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type incorrect, got %s, wanted %s", ct, "application/json")
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(respBody, testBody) {
+		t.Errorf("decoded response: got %+v, wanted %+v\n", respBody, testBody)
+	}
+
+}
+
+func TestServerSuccessfulResponse(t *testing.T) {
+	testResponse := []byte(`testing`)
+	s := NewServer()
+	s.Register(GitHubProvider, "pull_request", FromInterceptionFunc(func(r *http.Request, body []byte) ([]byte, error) {
+		return testResponse, nil
+	}))
+	r := makePullRequestRequest(t, []byte(`{}`))
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, r)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status code, got %d, wanted %d", resp.StatusCode, http.StatusOK)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type incorrect, got %s, wanted %s", ct, "application/json")
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(respBody, testResponse) {
+		t.Errorf("decoded response: got %+v, wanted %+v\n", respBody, testResponse)
+	}
+}
+
+func TestServerNoResponse(t *testing.T) {
+	s := NewServer()
+	s.Register(GitHubProvider, "pull_request", FromInterceptionFunc(func(r *http.Request, body []byte) ([]byte, error) {
+		return nil, nil
+	}))
+	r := makePullRequestRequest(t, []byte(`{}`))
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, r)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("unexpected status code, got %d, wanted %d", resp.StatusCode, http.StatusPreconditionFailed)
+	}
+	if h := resp.Header.Get(ReasonHeader); h != "not_matched" {
+		t.Errorf("%s got %q, wanted %q", ReasonHeader, h, "not_matched")
+	}
+}
+
+func TestServerWithDeniedDecision(t *testing.T) {
+	s := NewServer()
+	s.Register(GitHubProvider, "pull_request", InterceptorFunc(func(r *http.Request, body []byte) (*decision.Decision, error) {
+		return &decision.Decision{
+			Reason:  decision.ActionNotInList,
+			Message: "closed is not one of opened",
+			Status:  http.StatusForbidden,
+		}, nil
+	}))
+	r := makePullRequestRequest(t, []byte(`{}`))
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, r)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("unexpected status code, got %d, wanted %d", resp.StatusCode, http.StatusForbidden)
+	}
+	if h := resp.Header.Get(ReasonHeader); h != "action_not_in_list" {
+		t.Errorf("%s got %q, wanted %q", ReasonHeader, h, "action_not_in_list")
+	}
+	wantedMsg := "failed interception: action_not_in_list: closed is not one of opened\n"
+	if b := w.Body.String(); b != wantedMsg {
+		t.Fatalf("unexpected error message: got %q, wanted %q", b, wantedMsg)
+	}
+}
+
+func TestServerWithAllowedDecision(t *testing.T) {
+	s := NewServer()
+	s.Register(GitHubProvider, "pull_request", InterceptorFunc(func(r *http.Request, body []byte) (*decision.Decision, error) {
+		return decision.Allowed(map[string]interface{}{"fullname": "testing/testing"}), nil
+	}))
+	r := makePullRequestRequest(t, []byte(`{}`))
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, r)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status code, got %d, wanted %d", resp.StatusCode, http.StatusOK)
+	}
+	if h := resp.Header.Get(ReasonHeader); h != "matched" {
+		t.Errorf("%s got %q, wanted %q", ReasonHeader, h, "matched")
+	}
+	want := `{"intercepted":{"fullname":"testing/testing"}}`
+	if b := w.Body.String(); b != want {
+		t.Fatalf("got body %s, wanted %s", b, want)
+	}
+}
+
+func TestServerErrorResponse(t *testing.T) {
+	s := NewServer()
+	s.Register(GitHubProvider, "pull_request", FromInterceptionFunc(func(r *http.Request, body []byte) ([]byte, error) {
+		return nil, errors.New("test error")
+	}))
+	r := makePullRequestRequest(t, []byte(`{}`))
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, r)
+
+	resp := w.Result()
+	wantedStatus := http.StatusInternalServerError
+	if resp.StatusCode != wantedStatus {
+		t.Errorf("unexpected status code, got %d, wanted %d", resp.StatusCode, wantedStatus)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantedMsg := "failed handling the event: test error\n"
+	if string(body) != wantedMsg {
+		t.Fatalf("unexpected error message: got %s, wanted %s", body, wantedMsg)
+	}
+
+}
+
+func makePullRequestRequest(t *testing.T, body []byte) *http.Request {
+	r, err := http.NewRequest("POST", "/", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Add("Content-Type", "application/json")
+	r.Header.Add(gitHubEventHeader, "pull_request")
+	return r
+}
+
+func TestServerWithGitLabEvent(t *testing.T) {
+	testResponse := []byte(`testing`)
+	s := NewServer()
+	s.Register(GitLabProvider, gitlab.MergeRequestEvent, FromInterceptionFunc(func(r *http.Request, body []byte) ([]byte, error) {
+		return testResponse, nil
+	}))
+	r, err := http.NewRequest("POST", "/", bytes.NewReader([]byte(`{}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Add("Content-Type", "application/json")
+	r.Header.Add(gitlab.EventHeader, gitlab.MergeRequestEvent)
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, r)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status code, got %d, wanted %d", resp.StatusCode, http.StatusOK)
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(respBody, testResponse) {
+		t.Errorf("decoded response: got %+v, wanted %+v\n", respBody, testResponse)
+	}
+}
+
+func TestServerWithGiteaEvent(t *testing.T) {
+	var called string
+	s := NewServer()
+	s.Register(GiteaProvider, gitea.PushEvent, FromInterceptionFunc(func(r *http.Request, body []byte) ([]byte, error) {
+		called = "gitea"
+		return body, nil
+	}))
+	s.Register(GitHubProvider, "push", FromInterceptionFunc(func(r *http.Request, body []byte) ([]byte, error) {
+		called = "github"
+		return body, nil
+	}))
+	r, err := http.NewRequest("POST", "/", bytes.NewReader([]byte(`{}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Add(gitea.EventHeader, gitea.PushEvent)
+	r.Header.Add(gitHubEventHeader, "push")
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, r)
+
+	if called != "gitea" {
+		t.Fatalf("ServeHTTP() called the %q handler, wanted %q", called, "gitea")
+	}
+}
+
+func TestServerWithMetrics(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	s := NewServer(WithMetrics(metrics.New(reg)))
+	s.Register(GitHubProvider, "pull_request", FromInterceptionFunc(func(r *http.Request, body []byte) ([]byte, error) {
+		return nil, nil
+	}))
+	s.Register(GitHubProvider, "push", FromInterceptionFunc(func(r *http.Request, body []byte) ([]byte, error) {
+		return body, nil
+	}))
+	outcomeTests := []string{"pull_request", "push", "made_up_event"}
+
+	for _, eventType := range outcomeTests {
+		r := makePullRequestRequest(t, []byte(`{"repository":{"full_name":"testing/testing"}}`))
+		r.Header.Set(gitHubEventHeader, eventType)
+		s.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	want := `
+# HELP interceptor_hooks_total Count of hooks handled, by provider, event type, repository and outcome.
+# TYPE interceptor_hooks_total counter
+interceptor_hooks_total{event_type="pull_request",outcome="not-matched",provider="github",repo=""} 1
+interceptor_hooks_total{event_type="push",outcome="matched",provider="github",repo="testing/testing"} 1
+interceptor_hooks_total{event_type="unknown",outcome="passthrough",provider="github",repo=""} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "interceptor_hooks_total"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerWithMetricsRecordsVerifiedRepos(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	s := NewServer(WithMetrics(metrics.New(reg)), WithSecrets(secrets.New([]byte("secret"), nil)))
+	s.Register(GitHubProvider, "pull_request", FromInterceptionFunc(func(r *http.Request, body []byte) ([]byte, error) {
+		return nil, nil
+	}))
+	body := []byte(`{"repository":{"full_name":"testing/testing"}}`)
+	r := makePullRequestRequest(t, body)
+	r.Header.Set(signature.SHA256Header, sign256(body, "secret"))
+
+	s.ServeHTTP(httptest.NewRecorder(), r)
+
+	want := `
+# HELP interceptor_hooks_total Count of hooks handled, by provider, event type, repository and outcome.
+# TYPE interceptor_hooks_total counter
+interceptor_hooks_total{event_type="pull_request",outcome="not-matched",provider="github",repo="testing/testing"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "interceptor_hooks_total"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerWithMetricsRecordsRejectedSignatures(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	s := NewServer(WithMetrics(metrics.New(reg)), WithSecrets(secrets.New([]byte("secret"), nil)))
+	s.Register(GitHubProvider, "pull_request", InterceptorFunc(func(r *http.Request, body []byte) (*decision.Decision, error) {
+		return decision.Allowed(nil), nil
+	}))
+
+	for _, eventType := range []string{"pull_request", "made_up_event"} {
+		r := makePullRequestRequest(t, []byte(`{"repository":{"full_name":"testing/testing"}}`))
+		r.Header.Set(gitHubEventHeader, eventType)
+		s.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	want := `
+# HELP interceptor_hooks_total Count of hooks handled, by provider, event type, repository and outcome.
+# TYPE interceptor_hooks_total counter
+interceptor_hooks_total{event_type="pull_request",outcome="rejected",provider="github",repo=""} 1
+interceptor_hooks_total{event_type="unknown",outcome="rejected",provider="github",repo=""} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "interceptor_hooks_total"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerLogsDecisions(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	s := NewServer()
+	s.Register(GitHubProvider, "pull_request", InterceptorFunc(pullrequest.Intercept))
+	r := makePullRequestRequest(t, []byte(`{"action":"closed","repository":{"full_name":"testing/testing"}}`))
+	r.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+	r.Header.Set("Pullrequest-Action", "opened")
+	r.Header.Set("Pullrequest-Repo", "testing/testing")
+	r = r.WithContext(logging.WithLogger(r.Context(), zap.New(core)))
+
+	s.ServeHTTP(httptest.NewRecorder(), r)
+
+	entries := logs.FilterMessage("interception decision").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d decisions logged, wanted 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	want := map[string]interface{}{
+		"delivery": "72d3162e-cc78-11e3-81ab-4c9367dc0958",
+		"provider": GitHubProvider,
+		"event":    "pull_request",
+		"repo":     "testing/testing",
+		"action":   "closed",
+		"allowed":  false,
+		"reason":   string(decision.ActionNotInList),
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("field %s got %#v, want %#v", k, fields[k], v)
+		}
+	}
+}
+
+func TestServerWithSecrets(t *testing.T) {
+	store := secrets.New([]byte("secret"), nil)
+	s := NewServer(WithSecrets(store))
+	s.Register(GitHubProvider, "pull_request", InterceptorFunc(func(r *http.Request, body []byte) (*decision.Decision, error) {
+		return decision.Allowed(nil), nil
+	}))
+	body := []byte(`{"repository":{"full_name":"testing/testing"}}`)
+	signatureTests := []struct {
+		name       string
+		signature  string
+		wantStatus int
+	}{
+		{"valid signature", sign256(body, "secret"), http.StatusOK},
+		{"invalid signature", sign256(body, "other"), http.StatusForbidden},
+		{"no signature", "", http.StatusForbidden},
+	}
+
+	for _, tt := range signatureTests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := makePullRequestRequest(t, body)
+			if tt.signature != "" {
+				r.Header.Set(signature.SHA256Header, tt.signature)
+			}
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("unexpected status code, got %d, wanted %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestServerRegisterIsPerProvider(t *testing.T) {
+	s := NewServer()
+	s.Register(GitLabProvider, "push", FromInterceptionFunc(func(r *http.Request, body []byte) ([]byte, error) {
+		t.Fatal("GitLab push handler called")
+		return nil, nil
+	}))
+	r := makePullRequestRequest(t, []byte(`{}`))
+	r.Header.Set(gitHubEventHeader, "push")
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code, got %d, wanted %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestServerWithFilter(t *testing.T) {
+	s := NewServer()
+	s.Register(GitHubProvider, "pull_request", InterceptorFunc(func(r *http.Request, body []byte) (*decision.Decision, error) {
+		return decision.Allowed(nil), nil
+	}))
+	body := []byte(`{"pull_request":{"base":{"ref":"main"},"draft":false}}`)
+	filterTests := []struct {
+		filter     string
+		wantStatus int
+		wantReason string
+	}{
+		{"", http.StatusOK, "matched"},
+		{"body.pull_request.base.ref == 'main' && !body.pull_request.draft", http.StatusOK, "matched"},
+		{"body.pull_request.base.ref == 'develop'", http.StatusPreconditionFailed, "filter_rejected"},
+		{"body.pull_request.base.ref", http.StatusInternalServerError, ""},
+		{"body.pull_request.base.ref ==", http.StatusInternalServerError, ""},
+	}
+
+	for _, tt := range filterTests {
+		t.Run(tt.filter, func(t *testing.T) {
+			r := makePullRequestRequest(t, body)
+			r.Header.Set(FilterHeader, tt.filter)
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("unexpected status code, got %d, wanted %d", w.Code, tt.wantStatus)
+			}
+			if h := w.Header().Get(ReasonHeader); h != tt.wantReason {
+				t.Fatalf("%s got %q, wanted %q", ReasonHeader, h, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestServerWithFilterDoesNotOverrideDenied(t *testing.T) {
+	s := NewServer()
+	s.Register(GitHubProvider, "pull_request", InterceptorFunc(func(r *http.Request, body []byte) (*decision.Decision, error) {
+		return decision.Denied(decision.RepoMismatch), nil
+	}))
+	r := makePullRequestRequest(t, []byte(`{}`))
+	r.Header.Set(FilterHeader, "true")
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, r)
+
+	if h := w.Header().Get(ReasonHeader); h != "repo_mismatch" {
+		t.Fatalf("%s got %q, wanted %q", ReasonHeader, h, "repo_mismatch")
+	}
+}
+
+func TestServerWithOverlays(t *testing.T) {
+	s := NewServer()
+	s.Register(GitHubProvider, "pull_request", FromInterceptionFunc(func(r *http.Request, body []byte) ([]byte, error) {
+		return []byte(`{"intercepted":{"short_sha":"1234567"},"pull_request":{"number":2}}`), nil
+	}))
+	r := makePullRequestRequest(t, []byte(`{"pull_request":{"base":{"ref":"main"},"number":2}}`))
+	r.Header.Add(OverlayHeader, "base=body.pull_request.base.ref")
+	r.Header.Add(OverlayHeader, "event=header['X-Github-Event'] + '-' + string(int(body.pull_request.number))")
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code, got %d, wanted %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	want := `{"intercepted":{"base":"main","event":"pull_request-2","short_sha":"1234567"},"pull_request":{"number":2}}`
+	if b := w.Body.String(); b != want {
+		t.Fatalf("got body %s, wanted %s", b, want)
+	}
+}
+
+func TestServerWithShortSHALength(t *testing.T) {
+	s := NewServer(WithShortSHALength(8))
+	s.Register(GitHubProvider, "pull_request", InterceptorFunc(func(r *http.Request, body []byte) (*decision.Decision, error) {
+		e := &event.Event{Type: event.PullRequest, SHA: "6a6bcddc365ca3a38c9055a603c9590a7fae7ca6"}
+		return decision.AllowedEvent(e, map[string]interface{}{"short_sha": git.ShortenSHA(e.SHA)}), nil
+	}))
+	r := makePullRequestRequest(t, []byte(`{}`))
+	r.Header.Add(TemplateHeader, "tag={{ .ShortSHA }}")
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code, got %d, wanted %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	want := `{"intercepted":{"short_sha":"6a6bcddc","tag":"6a6bcddc"}}`
+	if b := w.Body.String(); b != want {
+		t.Fatalf("got body %s, wanted %s", b, want)
+	}
+}
@@ -0,0 +1,69 @@
+package interception
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/git"
+	"github.com/bigkevmcd/interceptor/pkg/templates"
+)
+
+const (
+	// TemplateHeader is a key=template pair, the rendered template is added
+	// to "intercepted" as the key, there can be multiple template headers.
+	TemplateHeader = "Interceptor-Template"
+
+	// OutputKeyHeader is the key that the intercepted values are added to
+	// the body as, instead of "intercepted".
+	OutputKeyHeader = "Interceptor-Output-Key"
+)
+
+// applyTemplates renders the templates in the request headers for an allowed
+// decision, and adds them to the intercepted values, and sets the output key
+// for the decision.
+//
+// The templates are rendered with the normalised event for the decision,
+// and the intercepted values, including any that were added to the body,
+// see templates.Model, if shaLength is set, the ShortSHA is shortened to
+// this length.
+func applyTemplates(t *templates.Renderer, r *http.Request, body []byte, d *decision.Decision, shaLength int) (*decision.Decision, error) {
+	if key := r.Header.Get(OutputKeyHeader); key != "" {
+		if !decision.ValidKey(key) {
+			return nil, fmt.Errorf("invalid %s %q", OutputKeyHeader, key)
+		}
+		d.Key = key
+	}
+	if len(r.Header[TemplateHeader]) == 0 {
+		return d, nil
+	}
+
+	m := templates.NewModel(d.Event, d.Values(body))
+	if d.Event != nil && shaLength != 0 {
+		m.ShortSHA = git.AbbreviateSHA(d.Event.SHA, shaLength)
+	}
+	for _, v := range r.Header[TemplateHeader] {
+		key, text, err := parseTemplate(v)
+		if err != nil {
+			return nil, err
+		}
+		result, err := t.Render(text, m)
+		if err != nil {
+			return nil, err
+		}
+		if d.Intercepted == nil {
+			d.Intercepted = map[string]interface{}{}
+		}
+		d.Intercepted[key] = result
+	}
+	return d, nil
+}
+
+// parseTemplate splits a template header into the key and the template.
+func parseTemplate(s string) (string, string, error) {
+	key, text, ok := splitKeyValue(s)
+	if !ok {
+		return "", "", fmt.Errorf("invalid template %q, must be key=template", s)
+	}
+	return key, text, nil
+}
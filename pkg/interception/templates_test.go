@@ -0,0 +1,96 @@
+package interception
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+)
+
+func TestParseTemplate(t *testing.T) {
+	templateTests := []struct {
+		template string
+		wantKey  string
+		wantText string
+		wantErr  bool
+	}{
+		{"image_tag={{ .Repo }}-{{ .ShortSHA }}", "image_tag", "{{ .Repo }}-{{ .ShortSHA }}", false},
+		{"name = {{ if eq .Branch \"main\" }}prod{{ end }}", "name", "{{ if eq .Branch \"main\" }}prod{{ end }}", false},
+		{"{{ .Repo }}", "", "", true},
+		{"=test", "", "", true},
+	}
+
+	for _, tt := range templateTests {
+		t.Run(tt.template, func(t *testing.T) {
+			key, text, err := parseTemplate(tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTemplate() got error %v, wanted error %v", err, tt.wantErr)
+			}
+			if key != tt.wantKey || text != tt.wantText {
+				t.Fatalf("parseTemplate() got %q, %q, wanted %q, %q", key, text, tt.wantKey, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestServerWithTemplates(t *testing.T) {
+	s := NewServer()
+	s.Register(GitHubProvider, "pull_request", InterceptorFunc(func(r *http.Request, body []byte) (*decision.Decision, error) {
+		e := &event.Event{Type: event.PullRequest, Repo: "bigkevmcd/interceptor", HeadRef: "feature/login", SHA: "6a6bcddc365ca3a38c9055a603c9590a7fae7ca6"}
+		return decision.AllowedEvent(e, map[string]interface{}{"fullname": e.Repo}), nil
+	}))
+	templateTests := []struct {
+		name       string
+		headers    http.Header
+		wantStatus int
+		want       string
+	}{
+		{"image tag", http.Header{TemplateHeader: {"image_tag={{ .Repo | dns1123 }}-{{ .Branch | dns1123 }}-{{ .ShortSHA }}"}},
+			http.StatusOK, `{"intercepted":{"fullname":"bigkevmcd/interceptor","image_tag":"bigkevmcd-interceptor-feature-login-6a6bcd"}}`},
+		{"output key", http.Header{TemplateHeader: {"name={{ .Intercepted.fullname }}"}, OutputKeyHeader: {"build"}},
+			http.StatusOK, `{"build":{"fullname":"bigkevmcd/interceptor","name":"bigkevmcd/interceptor"}}`},
+		{"invalid template", http.Header{TemplateHeader: {"name={{ .Unknown }}"}}, http.StatusInternalServerError, ""},
+		{"invalid output key", http.Header{OutputKeyHeader: {"build.info"}}, http.StatusInternalServerError, ""},
+	}
+
+	for _, tt := range templateTests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := makePullRequestRequest(t, []byte(`{}`))
+			for k, v := range tt.headers {
+				r.Header[k] = v
+			}
+			w := httptest.NewRecorder()
+
+			s.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("unexpected status code, got %d, wanted %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if b := w.Body.String(); tt.want != "" && b != tt.want {
+				t.Fatalf("got body %s, wanted %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerWithOutputKeyAndInterceptionFunc(t *testing.T) {
+	s := NewServer()
+	s.Register(GitHubProvider, "pull_request", FromInterceptionFunc(func(r *http.Request, body []byte) ([]byte, error) {
+		return []byte(`{"intercepted":{"short_sha":"1234567"},"pull_request":{"number":2}}`), nil
+	}))
+	r := makePullRequestRequest(t, []byte(`{"pull_request":{"number":2}}`))
+	r.Header.Set(OutputKeyHeader, "build")
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code, got %d, wanted %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	want := `{"build":{"short_sha":"1234567"},"pull_request":{"number":2}}`
+	if b := w.Body.String(); b != want {
+		t.Fatalf("got body %s, wanted %s", b, want)
+	}
+}
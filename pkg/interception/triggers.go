@@ -0,0 +1,225 @@
+package interception
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/logging"
+)
+
+// These are the types used by the Tekton Triggers ClusterInterceptor
+// protocol.
+//
+// See https://tekton.dev/docs/triggers/clusterinterceptors/
+
+// InterceptorRequest is the request sent by Tekton Triggers to a
+// ClusterInterceptor.
+type InterceptorRequest struct {
+	Body              string                 `json:"body,omitempty"`
+	Header            map[string][]string    `json:"header,omitempty"`
+	Extensions        map[string]interface{} `json:"extensions,omitempty"`
+	InterceptorParams map[string]interface{} `json:"interceptor_params,omitempty"`
+	Context           *TriggerContext        `json:"context"`
+}
+
+// TriggerContext describes the event and trigger being processed.
+type TriggerContext struct {
+	EventURL  string `json:"event_url,omitempty"`
+	EventID   string `json:"event_id,omitempty"`
+	TriggerID string `json:"trigger_id,omitempty"`
+}
+
+// InterceptorResponse is the response returned to Tekton Triggers.
+type InterceptorResponse struct {
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+	Continue   bool                   `json:"continue"`
+	Status     Status                 `json:"status"`
+}
+
+// Status is the status of the interception, the code is a gRPC status code.
+type Status struct {
+	Code    StatusCode `json:"code"`
+	Message string     `json:"message,omitempty"`
+}
+
+// StatusCode is a gRPC status code.
+type StatusCode int
+
+// These are the gRPC status codes returned by the TriggersHandler.
+const (
+	StatusOK                 StatusCode = 0
+	StatusInvalidArgument    StatusCode = 3
+	StatusNotFound           StatusCode = 5
+	StatusPermissionDenied   StatusCode = 7
+	StatusResourceExhausted  StatusCode = 8
+	StatusFailedPrecondition StatusCode = 9
+	StatusInternal           StatusCode = 13
+	StatusUnauthenticated    StatusCode = 16
+)
+
+// TriggersHandler wraps a handler that speaks the webhook interceptor
+// protocol, and implements the Tekton Triggers InterceptorRequest and
+// InterceptorResponse protocol on top of it.
+//
+// The body and headers of the original hook are passed to the wrapped
+// handler, with the interceptor_params added as headers, so that settings
+// like Push-Ref and Pullrequest-Action can be provided as params.
+//
+// If the wrapped handler allows the hook, the "intercepted" values it added to
+// the body are returned as the "intercepted" extension, rather than being
+// added to the body, or as the Interceptor-Output-Key extension if this is
+// provided.
+func TriggersHandler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			msg := fmt.Sprintf("failed to read the request body: %s", err.Error())
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		var ireq InterceptorRequest
+		if err := json.Unmarshal(body, &ireq); err != nil {
+			msg := fmt.Sprintf("failed to parse the interceptor request: %s", err.Error())
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		hookReq, err := makeHookRequest(r, &ireq)
+		if err != nil {
+			writeInterceptorResponse(w, &InterceptorResponse{
+				Status: Status{Code: StatusInvalidArgument, Message: err.Error()},
+			})
+			return
+		}
+		buf := newResponseBuffer()
+		next(buf, hookReq)
+		writeInterceptorResponse(w, makeInterceptorResponse(buf, outputKey(hookReq)))
+	}
+}
+
+func makeHookRequest(r *http.Request, ireq *InterceptorRequest) (*http.Request, error) {
+	hookReq, err := http.NewRequest(http.MethodPost, r.URL.String(), bytes.NewReader([]byte(ireq.Body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the hook request: %w", err)
+	}
+	hookReq = hookReq.WithContext(r.Context())
+	for k, v := range ireq.Header {
+		for _, s := range v {
+			hookReq.Header.Add(k, s)
+		}
+	}
+	for k, v := range ireq.InterceptorParams {
+		params := []interface{}{v}
+		if list, ok := v.([]interface{}); ok && isListParam(k) {
+			params = list
+		}
+		hookReq.Header.Del(k)
+		for _, p := range params {
+			param, err := paramToHeader(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid interceptor param %q: %w", k, err)
+			}
+			hookReq.Header.Add(k, param)
+		}
+	}
+	return hookReq, nil
+}
+
+// isListParam returns true for the params where each value in a list is
+// added as a separate header, as the values can contain commas.
+func isListParam(k string) bool {
+	k = http.CanonicalHeaderKey(k)
+	return k == OverlayHeader || k == TemplateHeader
+}
+
+// paramToHeader converts an interceptor param to a header value, lists are
+// converted to comma-separated values.
+//
+// The Interceptor-Overlay and Interceptor-Template params are the exception,
+// as expressions and templates can contain commas, each value in the list is
+// added as a separate header.
+func paramToHeader(v interface{}) (string, error) {
+	switch p := v.(type) {
+	case string:
+		return p, nil
+	case bool, float64:
+		return fmt.Sprint(p), nil
+	case []interface{}:
+		values := []string{}
+		for _, i := range p {
+			s, err := paramToHeader(i)
+			if err != nil {
+				return "", err
+			}
+			values = append(values, s)
+		}
+		return strings.Join(values, ","), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
+
+// outputKey returns the key that the intercepted values are added to the
+// body as.
+func outputKey(r *http.Request) string {
+	if key := r.Header.Get(OutputKeyHeader); key != "" {
+		return key
+	}
+	return decision.DefaultKey
+}
+
+func makeInterceptorResponse(buf *responseBuffer, key string) *InterceptorResponse {
+	respBody := buf.body.Bytes()
+	if buf.code >= 200 && buf.code < 300 {
+		resp := &InterceptorResponse{Continue: true, Status: Status{Code: StatusOK}}
+		if intercepted := gjson.GetBytes(respBody, key); intercepted.Exists() {
+			resp.Extensions = map[string]interface{}{
+				key: intercepted.Value(),
+			}
+		}
+		return resp
+	}
+	return &InterceptorResponse{Status: Status{Code: statusCode(buf.code), Message: responseMessage(respBody)}}
+}
+
+// statusCode returns the gRPC status code for the HTTP status of a hook that
+// was not allowed.
+//
+// 4xx statuses without a more specific code are StatusInvalidArgument, and
+// all other statuses are StatusInternal.
+func statusCode(code int) StatusCode {
+	switch code {
+	case http.StatusUnauthorized:
+		return StatusUnauthenticated
+	case http.StatusForbidden:
+		return StatusPermissionDenied
+	case http.StatusNotFound:
+		return StatusNotFound
+	case http.StatusConflict, http.StatusPreconditionFailed, http.StatusPreconditionRequired:
+		return StatusFailedPrecondition
+	case http.StatusTooManyRequests:
+		return StatusResourceExhausted
+	}
+	if code >= 400 && code < 500 {
+		return StatusInvalidArgument
+	}
+	return StatusInternal
+}
+
+func responseMessage(b []byte) string {
+	return strings.TrimSpace(string(b))
+}
+
+func writeInterceptorResponse(w http.ResponseWriter, resp *InterceptorResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logging.Default().Error("failed to write the interceptor response", zap.Error(err))
+	}
+}
@@ -0,0 +1,187 @@
+package interception
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestTriggersHandlerPassesHookToHandler(t *testing.T) {
+	var hookBody []byte
+	var hookHeaders http.Header
+	h := TriggersHandler(func(w http.ResponseWriter, r *http.Request) {
+		hookBody, _ = ioutil.ReadAll(r.Body)
+		hookHeaders = r.Header
+		w.Write([]byte(`{"intercepted":{"short_sha":"abc123"}}`))
+	})
+	ireq := &InterceptorRequest{
+		Body:   `{"testing":"value"}`,
+		Header: map[string][]string{gitHubEventHeader: {"push"}},
+		InterceptorParams: map[string]interface{}{
+			"Push-Repo":          "testing/testing",
+			"Pullrequest-Action": []interface{}{"opened", "synchronize"},
+			OverlayHeader:        []interface{}{"base=body.base", "refs=[body.base, body.head]"},
+		},
+	}
+
+	resp := sendInterceptorRequest(t, h, ireq)
+
+	if string(hookBody) != ireq.Body {
+		t.Errorf("hook body got %s, wanted %s", hookBody, ireq.Body)
+	}
+	wantHeaders := map[string]string{
+		gitHubEventHeader:    "push",
+		"Push-Repo":          "testing/testing",
+		"Pullrequest-Action": "opened,synchronize",
+	}
+	for k, v := range wantHeaders {
+		if h := hookHeaders.Get(k); h != v {
+			t.Errorf("hook header %s got %q, wanted %q", k, h, v)
+		}
+	}
+	wantOverlays := []string{"base=body.base", "refs=[body.base, body.head]"}
+	if v := hookHeaders[OverlayHeader]; !reflect.DeepEqual(v, wantOverlays) {
+		t.Errorf("hook header %s got %q, wanted %q", OverlayHeader, v, wantOverlays)
+	}
+	want := &InterceptorResponse{
+		Continue: true,
+		Status:   Status{Code: StatusOK},
+		Extensions: map[string]interface{}{
+			"intercepted": map[string]interface{}{"short_sha": "abc123"},
+		},
+	}
+	if !reflect.DeepEqual(resp, want) {
+		t.Fatalf("got response %#v, wanted %#v", resp, want)
+	}
+}
+
+func TestTriggersHandlerResponses(t *testing.T) {
+	responseTests := []struct {
+		name   string
+		status int
+		body   string
+		want   *InterceptorResponse
+	}{
+		{"passthrough", http.StatusOK, `{}`, &InterceptorResponse{Continue: true, Status: Status{Code: StatusOK}}},
+		{"accepted", http.StatusAccepted, `{}`, &InterceptorResponse{Continue: true, Status: Status{Code: StatusOK}}},
+		{"no content", http.StatusNoContent, ``, &InterceptorResponse{Continue: true, Status: Status{Code: StatusOK}}},
+		{"failed interception", http.StatusPreconditionFailed, "failed interception\n",
+			&InterceptorResponse{Status: Status{Code: StatusFailedPrecondition, Message: "failed interception"}}},
+		{"forbidden", http.StatusForbidden, "invalid signature\n",
+			&InterceptorResponse{Status: Status{Code: StatusPermissionDenied, Message: "invalid signature"}}},
+		{"bad request", http.StatusBadRequest, "invalid hook\n",
+			&InterceptorResponse{Status: Status{Code: StatusInvalidArgument, Message: "invalid hook"}}},
+		{"unprocessable", http.StatusUnprocessableEntity, "invalid hook\n",
+			&InterceptorResponse{Status: Status{Code: StatusInvalidArgument, Message: "invalid hook"}}},
+		{"unauthorized", http.StatusUnauthorized, "no token\n",
+			&InterceptorResponse{Status: Status{Code: StatusUnauthenticated, Message: "no token"}}},
+		{"not found", http.StatusNotFound, "unknown rule\n",
+			&InterceptorResponse{Status: Status{Code: StatusNotFound, Message: "unknown rule"}}},
+		{"conflict", http.StatusConflict, "already running\n",
+			&InterceptorResponse{Status: Status{Code: StatusFailedPrecondition, Message: "already running"}}},
+		{"too many requests", http.StatusTooManyRequests, "rate limited\n",
+			&InterceptorResponse{Status: Status{Code: StatusResourceExhausted, Message: "rate limited"}}},
+		{"error", http.StatusInternalServerError, "failed handling the event\n",
+			&InterceptorResponse{Status: Status{Code: StatusInternal, Message: "failed handling the event"}}},
+	}
+
+	for _, tt := range responseTests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := TriggersHandler(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			resp := sendInterceptorRequest(t, h, &InterceptorRequest{Body: `{}`})
+
+			if !reflect.DeepEqual(resp, tt.want) {
+				t.Fatalf("got response %#v, wanted %#v", resp, tt.want)
+			}
+		})
+	}
+}
+
+func TestTriggersHandlerWithOutputKey(t *testing.T) {
+	var hookHeaders http.Header
+	h := TriggersHandler(func(w http.ResponseWriter, r *http.Request) {
+		hookHeaders = r.Header
+		w.Write([]byte(`{"build":{"image_tag":"testing-abc123"}}`))
+	})
+	ireq := &InterceptorRequest{
+		Body: `{}`,
+		InterceptorParams: map[string]interface{}{
+			OutputKeyHeader: "build",
+			TemplateHeader:  []interface{}{"image_tag={{ .Repo }}-{{ .ShortSHA }}", `name={{ .Branch | trimPrefix "feature/" }}`},
+		},
+	}
+
+	resp := sendInterceptorRequest(t, h, ireq)
+
+	wantTemplates := []string{"image_tag={{ .Repo }}-{{ .ShortSHA }}", `name={{ .Branch | trimPrefix "feature/" }}`}
+	if v := hookHeaders[TemplateHeader]; !reflect.DeepEqual(v, wantTemplates) {
+		t.Errorf("hook header %s got %q, wanted %q", TemplateHeader, v, wantTemplates)
+	}
+	want := &InterceptorResponse{
+		Continue: true,
+		Status:   Status{Code: StatusOK},
+		Extensions: map[string]interface{}{
+			"build": map[string]interface{}{"image_tag": "testing-abc123"},
+		},
+	}
+	if !reflect.DeepEqual(resp, want) {
+		t.Fatalf("got response %#v, wanted %#v", resp, want)
+	}
+}
+
+func TestTriggersHandlerWithInvalidParam(t *testing.T) {
+	h := TriggersHandler(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler called with an invalid param")
+	})
+	ireq := &InterceptorRequest{
+		Body:              `{}`,
+		InterceptorParams: map[string]interface{}{"Push-Ref": map[string]interface{}{}},
+	}
+
+	resp := sendInterceptorRequest(t, h, ireq)
+
+	if resp.Continue || resp.Status.Code != StatusInvalidArgument {
+		t.Fatalf("got response %#v, wanted invalid argument", resp)
+	}
+}
+
+func TestTriggersHandlerWithInvalidRequest(t *testing.T) {
+	h := TriggersHandler(func(w http.ResponseWriter, r *http.Request) {})
+	r := httptest.NewRequest("POST", "/", bytes.NewReader([]byte(`{test`)))
+	w := httptest.NewRecorder()
+
+	h(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status code, got %d, wanted %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func sendInterceptorRequest(t *testing.T, h http.HandlerFunc, ireq *InterceptorRequest) *InterceptorResponse {
+	t.Helper()
+	b, err := json.Marshal(ireq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("POST", "/", bytes.NewReader(b))
+	w := httptest.NewRecorder()
+
+	h(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code, got %d, wanted %d", w.Code, http.StatusOK)
+	}
+	var resp InterceptorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return &resp
+}
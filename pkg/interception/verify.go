@@ -0,0 +1,84 @@
+package interception
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/bigkevmcd/interceptor/pkg/interception/bitbucket"
+	"github.com/bigkevmcd/interceptor/pkg/interception/gitea"
+	"github.com/bigkevmcd/interceptor/pkg/interception/gitlab"
+	"github.com/bigkevmcd/interceptor/pkg/logging"
+	"github.com/bigkevmcd/interceptor/pkg/secrets"
+	"github.com/bigkevmcd/interceptor/pkg/signature"
+)
+
+// VerifySignatures wraps a handler, and rejects requests that are not
+// correctly signed with the secret configured for the repository in the hook
+// body.
+//
+// GitHub hooks are verified using the HMAC signature headers, Bitbucket hooks
+// using the HMAC-SHA256 signature in the X-Hub-Signature header, Gitea and
+// Forgejo hooks using their own HMAC signature headers, and GitLab hooks by
+// comparing the X-Gitlab-Token header with the secret.
+//
+// Requests that fail verification are rejected with a 403 Forbidden
+// response, and are not passed on to the wrapped handler.
+func VerifySignatures(s secrets.Getter, next http.HandlerFunc) http.HandlerFunc {
+	return verifySignatures(s, next, func(*http.Request) {})
+}
+
+// verifySignatures is VerifySignatures, calling rejected for each request
+// that fails verification.
+func verifySignatures(s secrets.Getter, next http.HandlerFunc, rejected func(*http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			msg := fmt.Sprintf("failed to read the request body: %s", err.Error())
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		repo := hookRepoName(r, body)
+		if err := verifyRequest(s, r, repo, body); err != nil {
+			fields := append(requestFields(r), zap.String("repo", repo), zap.Error(err))
+			logging.FromContext(r.Context()).Warn("rejecting hook with invalid signature", fields...)
+			msg := fmt.Sprintf("failed to verify the hook signature: %s", err.Error())
+			http.Error(w, msg, http.StatusForbidden)
+			rejected(r)
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		next(w, r)
+	}
+}
+
+func verifyRequest(s secrets.Getter, r *http.Request, repo string, body []byte) error {
+	secret, err := s.Secret(repo)
+	if err != nil {
+		return err
+	}
+	if gitea.EventType(r) != "" {
+		sig := gitea.Signature(r)
+		if sig == "" {
+			return signature.ErrMissingSignature
+		}
+		return signature.ValidateHex(sha256.New, sig, body, secret)
+	}
+	if r.Header.Get(gitlab.EventHeader) != "" {
+		return signature.ValidateToken(r.Header.Get(gitlab.TokenHeader), secret)
+	}
+	if r.Header.Get(bitbucket.EventHeader) != "" {
+		sig := r.Header.Get(bitbucket.SignatureHeader)
+		if sig == "" {
+			return signature.ErrMissingSignature
+		}
+		return signature.ValidateAlgorithm(signature.SHA256, sig, body, secret)
+	}
+	return signature.ValidateRequest(r, body, secret)
+}
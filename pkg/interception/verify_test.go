@@ -0,0 +1,208 @@
+package interception
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bigkevmcd/interceptor/pkg/interception/bitbucket"
+	"github.com/bigkevmcd/interceptor/pkg/interception/gitea"
+	"github.com/bigkevmcd/interceptor/pkg/interception/gitlab"
+	"github.com/bigkevmcd/interceptor/pkg/secrets"
+	"github.com/bigkevmcd/interceptor/pkg/signature"
+)
+
+const testHookBody = `{"repository":{"full_name":"testing/repo"}}`
+
+func TestVerifySignatures(t *testing.T) {
+	store := secrets.New(nil, map[string][]byte{"testing/repo": []byte("secret")})
+	body := []byte(testHookBody)
+
+	verifyTests := []struct {
+		name       string
+		header     string
+		sig        string
+		wantStatus int
+	}{
+		{"valid sha256", signature.SHA256Header, sign256(body, "secret"), http.StatusOK},
+		{"valid sha1", signature.SHA1Header, sign1(body, "secret"), http.StatusOK},
+		{"wrong secret", signature.SHA256Header, sign256(body, "other"), http.StatusForbidden},
+		{"sha1 in the sha256 header", signature.SHA256Header, sign1(body, "secret"), http.StatusForbidden},
+		{"no signature", "", "", http.StatusForbidden},
+	}
+
+	for _, tt := range verifyTests {
+		t.Run(tt.name, func(t *testing.T) {
+			var nextBody []byte
+			h := VerifySignatures(store, func(w http.ResponseWriter, r *http.Request) {
+				nextBody, _ = ioutil.ReadAll(r.Body)
+			})
+			r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+			if tt.header != "" {
+				r.Header.Add(tt.header, tt.sig)
+			}
+			w := httptest.NewRecorder()
+
+			h(w, r)
+
+			resp := w.Result()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("unexpected status code, got %d, wanted %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && !bytes.Equal(nextBody, body) {
+				t.Fatalf("wrapped handler got body %s, wanted %s", nextBody, body)
+			}
+		})
+	}
+}
+
+func TestVerifySignaturesWithUnknownRepo(t *testing.T) {
+	store := secrets.New(nil, map[string][]byte{"testing/other": []byte("secret")})
+	body := []byte(testHookBody)
+	h := VerifySignatures(store, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("wrapped handler called for an unknown repo")
+	})
+	r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+	r.Header.Add(signature.SHA256Header, sign256(body, "secret"))
+	w := httptest.NewRecorder()
+
+	h(w, r)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("unexpected status code, got %d, wanted %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func sign256(body []byte, secret string) string {
+	return "sha256=" + hex.EncodeToString(signature.Sign(sha256.New, body, []byte(secret)))
+}
+
+func sign1(body []byte, secret string) string {
+	return "sha1=" + hex.EncodeToString(signature.Sign(sha1.New, body, []byte(secret)))
+}
+
+func TestVerifySignaturesWithGitLabToken(t *testing.T) {
+	store := secrets.New(nil, map[string][]byte{"testing/repo": []byte("secret")})
+	body := []byte(`{"project":{"path_with_namespace":"testing/repo"}}`)
+
+	tokenTests := []struct {
+		token      string
+		wantStatus int
+	}{
+		{"secret", http.StatusOK},
+		{"other", http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+
+	for _, tt := range tokenTests {
+		h := VerifySignatures(store, func(w http.ResponseWriter, r *http.Request) {})
+		r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+		r.Header.Add(gitlab.EventHeader, gitlab.PushEvent)
+		if tt.token != "" {
+			r.Header.Add(gitlab.TokenHeader, tt.token)
+		}
+		w := httptest.NewRecorder()
+
+		h(w, r)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("token %q got status %d, wanted %d", tt.token, w.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestVerifySignaturesWithBitbucketServer(t *testing.T) {
+	store := secrets.New(nil, map[string][]byte{"PROJ/testing": []byte("secret")})
+	bodyTests := []struct {
+		eventKey string
+		body     string
+	}{
+		{bitbucket.ServerPushEvent, `{"repository":{"slug":"testing","project":{"key":"PROJ"}}}`},
+		{"pr:opened", `{"pullRequest":{"toRef":{"repository":{"slug":"testing","project":{"key":"PROJ"}}}}}`},
+	}
+
+	for _, tt := range bodyTests {
+		body := []byte(tt.body)
+		h := VerifySignatures(store, func(w http.ResponseWriter, r *http.Request) {})
+		r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+		r.Header.Add(bitbucket.EventHeader, tt.eventKey)
+		r.Header.Add(bitbucket.SignatureHeader, sign256(body, "secret"))
+		w := httptest.NewRecorder()
+
+		h(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("%s got status %d, wanted %d", tt.eventKey, w.Code, http.StatusOK)
+		}
+	}
+}
+
+func TestVerifySignaturesWithBitbucketCloud(t *testing.T) {
+	store := secrets.New(nil, map[string][]byte{"testing/repo": []byte("secret")})
+	body := []byte(testHookBody)
+	sigTests := []struct {
+		name       string
+		sig        string
+		wantStatus int
+	}{
+		{"valid sha256", sign256(body, "secret"), http.StatusOK},
+		{"wrong secret", sign256(body, "other"), http.StatusForbidden},
+		{"sha1", sign1(body, "secret"), http.StatusForbidden},
+		{"no signature", "", http.StatusForbidden},
+	}
+
+	for _, tt := range sigTests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := VerifySignatures(store, func(w http.ResponseWriter, r *http.Request) {})
+			r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+			r.Header.Add(bitbucket.EventHeader, bitbucket.CloudPushEvent)
+			if tt.sig != "" {
+				r.Header.Add(bitbucket.SignatureHeader, tt.sig)
+			}
+			w := httptest.NewRecorder()
+
+			h(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("got status %d, wanted %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestVerifySignaturesWithGitea(t *testing.T) {
+	store := secrets.New(nil, map[string][]byte{"testing/repo": []byte("secret")})
+	body := []byte(testHookBody)
+	validSig := hex.EncodeToString(signature.Sign(sha256.New, body, []byte("secret")))
+
+	giteaTests := []struct {
+		eventHeader string
+		sigHeader   string
+		sig         string
+		wantStatus  int
+	}{
+		{gitea.EventHeader, gitea.SignatureHeader, validSig, http.StatusOK},
+		{gitea.ForgejoEventHeader, gitea.ForgejoSignatureHeader, validSig, http.StatusOK},
+		{gitea.EventHeader, gitea.SignatureHeader, "abcdef", http.StatusForbidden},
+		{gitea.EventHeader, signature.SHA256Header, "sha256=" + validSig, http.StatusForbidden},
+	}
+
+	for _, tt := range giteaTests {
+		h := VerifySignatures(store, func(w http.ResponseWriter, r *http.Request) {})
+		r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+		r.Header.Add(tt.eventHeader, gitea.PushEvent)
+		r.Header.Add(tt.sigHeader, tt.sig)
+		w := httptest.NewRecorder()
+
+		h(w, r)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: %s got status %d, wanted %d", tt.eventHeader, tt.sigHeader, w.Code, tt.wantStatus)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+)
+
+type loggerKey struct{}
+
+var fallbackLogger = zap.NewNop()
+
+// New creates a JSON logger that logs at the provided level, one of "debug",
+// "info", "warn" or "error".
+func New(level string) (*zap.Logger, error) {
+	var l zapcore.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	cfg := zap.NewProductionConfig()
+	cfg.Level = zap.NewAtomicLevelAt(l)
+	cfg.EncoderConfig.TimeKey = "time"
+	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	return cfg.Build()
+}
+
+// SetDefault sets the logger that is returned by FromContext if there is no
+// logger in the context.
+func SetDefault(l *zap.Logger) {
+	fallbackLogger = l
+}
+
+// Default returns the logger that is used when there is no logger in the
+// context.
+func Default() *zap.Logger {
+	return fallbackLogger
+}
+
+// WithLogger returns a context with the logger.
+func WithLogger(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// WithFields returns a context with a logger that has the fields added to
+// the logger in the context.
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	return WithLogger(ctx, FromContext(ctx).With(fields...))
+}
+
+// FromContext returns the logger in the context, or the default logger if
+// there is none.
+func FromContext(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok {
+		return l
+	}
+	return Default()
+}
+
+// Decision logs the decision for a hook, with the reason, and any fields
+// that identify what was matched e.g. the repo and ref.
+func Decision(ctx context.Context, reason decision.Reason, fields ...zap.Field) {
+	fields = append(fields,
+		zap.Bool("allowed", reason == decision.Matched),
+		zap.String("reason", string(reason)))
+	FromContext(ctx).Info("interception decision", fields...)
+}
@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+)
+
+func TestNew(t *testing.T) {
+	l, err := New("debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !l.Core().Enabled(zap.DebugLevel) {
+		t.Fatal("New(debug) did not enable debug logging")
+	}
+
+	l, err = New("warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Core().Enabled(zap.InfoLevel) {
+		t.Fatal("New(warn) enabled info logging")
+	}
+}
+
+func TestNewWithInvalidLevel(t *testing.T) {
+	_, err := New("loud")
+	if err == nil {
+		t.Fatal("expected an error with an invalid level")
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	ctx := WithLogger(context.Background(), zap.New(core))
+	ctx = WithFields(ctx, zap.String("delivery", "1234"))
+
+	FromContext(ctx).Info("testing", zap.String("reason", "repo_mismatch"))
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, wanted 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["delivery"] != "1234" || fields["reason"] != "repo_mismatch" {
+		t.Fatalf("got fields %#v", fields)
+	}
+}
+
+func TestFromContextWithNoLogger(t *testing.T) {
+	if FromContext(context.Background()) == nil {
+		t.Fatal("FromContext() got nil")
+	}
+}
+
+func TestDecision(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	ctx := WithLogger(context.Background(), zap.New(core))
+
+	Decision(ctx, decision.RefExcluded, zap.String("repo", "testing/repo"))
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, wanted 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	want := map[string]interface{}{
+		"repo":    "testing/repo",
+		"allowed": false,
+		"reason":  "ref_excluded",
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("field %s got %#v, want %#v", k, fields[k], v)
+		}
+	}
+}
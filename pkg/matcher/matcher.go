@@ -0,0 +1,202 @@
+// Package matcher provides matchers for normalised events, that can be
+// combined to build up the conditions for a hook to be intercepted.
+package matcher
+
+import (
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/pattern"
+)
+
+// Matcher matches events, returning the reason for the decision.
+type Matcher interface {
+	Match(e *event.Event) (bool, decision.Reason)
+}
+
+// Func is an adapter to allow ordinary functions to be used as Matchers.
+type Func func(e *event.Event) (bool, decision.Reason)
+
+// Match calls f(e).
+func (f Func) Match(e *event.Event) (bool, decision.Reason) {
+	return f(e)
+}
+
+// Type matches events of the type.
+func Type(eventType string) Matcher {
+	return Func(func(e *event.Event) (bool, decision.Reason) {
+		return result(e.Type == eventType, decision.EventMismatch)
+	})
+}
+
+// Repo matches events for any of the repositories.
+func Repo(repos []string) Matcher {
+	return Func(func(e *event.Event) (bool, decision.Reason) {
+		return result(contains(repos, e.Repo), decision.RepoMismatch)
+	})
+}
+
+// Ref matches events with a ref that matches one of the included patterns,
+// and none of the excluded patterns, excluded patterns take precedence.
+//
+// If there are no included patterns, all refs that are not excluded match.
+func Ref(include, exclude pattern.List) Matcher {
+	return Func(func(e *event.Event) (bool, decision.Reason) {
+		if exclude.Match(e.Ref) {
+			return false, decision.RefExcluded
+		}
+		return result(include.Empty() || include.Match(e.Ref), decision.RefNotIncluded)
+	})
+}
+
+// BaseRef matches pull request events with a base branch that matches one
+// of the patterns, if there are no patterns, all events match.
+func BaseRef(include pattern.List) Matcher {
+	return Func(func(e *event.Event) (bool, decision.Reason) {
+		return result(include.Empty() || include.Match(e.BaseRef), decision.BaseRefNotIncluded)
+	})
+}
+
+// HeadRef matches pull request events with a head branch that matches one
+// of the patterns, if there are no patterns, all events match.
+func HeadRef(include pattern.List) Matcher {
+	return Func(func(e *event.Event) (bool, decision.Reason) {
+		return result(include.Empty() || include.Match(e.HeadRef), decision.HeadRefNotIncluded)
+	})
+}
+
+// Draft matches pull request events that are not drafts, unless drafts are
+// included.
+func Draft(include bool) Matcher {
+	return Func(func(e *event.Event) (bool, decision.Reason) {
+		return result(include || !e.Draft, decision.DraftPullRequest)
+	})
+}
+
+// Created matches push events that didn't create a ref, unless creations
+// are included.
+func Created(include bool) Matcher {
+	return Func(func(e *event.Event) (bool, decision.Reason) {
+		return result(include || !e.Created, decision.RefCreated)
+	})
+}
+
+// Deleted matches push events that didn't delete a ref, unless deletions
+// are included.
+func Deleted(include bool) Matcher {
+	return Func(func(e *event.Event) (bool, decision.Reason) {
+		return result(include || !e.Deleted, decision.RefDeleted)
+	})
+}
+
+// Forced matches push events that were not force pushes, unless force
+// pushes are included.
+func Forced(include bool) Matcher {
+	return Func(func(e *event.Event) (bool, decision.Reason) {
+		return result(include || !e.Forced, decision.ForcedPush)
+	})
+}
+
+// Labels matches pull request events with one of the included labels, and
+// none of the excluded labels, excluded labels take precedence.
+//
+// If there are no included labels, all events without excluded labels
+// match.
+//
+// For "labeled" and "unlabeled" events, the label that was changed must be
+// one of the included or excluded labels, so that only changes to the
+// labels that affect the match are matched.
+func Labels(include, exclude []string) Matcher {
+	return Func(func(e *event.Event) (bool, decision.Reason) {
+		if containsAny(exclude, e.Labels) {
+			return false, decision.LabelExcluded
+		}
+		if len(include) > 0 && !containsAny(include, e.Labels) {
+			return false, decision.LabelNotIncluded
+		}
+		if e.Action == "labeled" || e.Action == "unlabeled" {
+			changed := len(include) == 0 && len(exclude) == 0 ||
+				contains(include, e.Label) || contains(exclude, e.Label)
+			return result(changed, decision.LabelChangeIgnored)
+		}
+		return true, decision.Matched
+	})
+}
+
+// Action matches events with any of the actions.
+func Action(actions []string) Matcher {
+	return Func(func(e *event.Event) (bool, decision.Reason) {
+		return result(contains(actions, e.Action), decision.ActionNotInList)
+	})
+}
+
+// Sender matches events triggered by any of the senders, if there are no
+// senders, all events match.
+func Sender(senders []string) Matcher {
+	return Func(func(e *event.Event) (bool, decision.Reason) {
+		return result(len(senders) == 0 || contains(senders, e.Sender), decision.SenderNotAllowed)
+	})
+}
+
+// All matches if all the matchers match, the reason is from the first
+// matcher that doesn't match.
+func All(ms ...Matcher) Matcher {
+	return Func(func(e *event.Event) (bool, decision.Reason) {
+		for _, m := range ms {
+			if ok, reason := m.Match(e); !ok {
+				return false, reason
+			}
+		}
+		return true, decision.Matched
+	})
+}
+
+// Any matches if any of the matchers match, if none match, the reason is
+// from the last matcher.
+func Any(ms ...Matcher) Matcher {
+	return Func(func(e *event.Event) (bool, decision.Reason) {
+		reason := decision.NotMatched
+		for _, m := range ms {
+			var ok bool
+			if ok, reason = m.Match(e); ok {
+				return true, decision.Matched
+			}
+		}
+		return false, reason
+	})
+}
+
+// Not matches if the matcher doesn't match, and rejects with the reason if
+// it does.
+func Not(m Matcher, reason decision.Reason) Matcher {
+	return Func(func(e *event.Event) (bool, decision.Reason) {
+		if ok, _ := m.Match(e); ok {
+			return false, reason
+		}
+		return true, decision.Matched
+	})
+}
+
+func result(ok bool, reason decision.Reason) (bool, decision.Reason) {
+	if ok {
+		return true, decision.Matched
+	}
+	return false, reason
+}
+
+func containsAny(values, s []string) bool {
+	for _, v := range s {
+		if contains(values, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/pattern"
+)
+
+func TestMatchers(t *testing.T) {
+	testEvent := &event.Event{
+		Type:    event.Push,
+		Repo:    "testing/testing",
+		Ref:     "release/v1.0",
+		Action:  "opened",
+		Sender:  "octocat",
+		BaseRef: "main",
+		HeadRef: "feature/login",
+	}
+	matcherTests := []struct {
+		name    string
+		matcher Matcher
+		want    bool
+		reason  decision.Reason
+	}{
+		{"type", Type(event.Push), true, decision.Matched},
+		{"other type", Type(event.PullRequest), false, decision.EventMismatch},
+		{"repo", Repo([]string{"testing/other", "testing/testing"}), true, decision.Matched},
+		{"other repo", Repo([]string{"testing/other"}), false, decision.RepoMismatch},
+		{"no repos", Repo(nil), false, decision.RepoMismatch},
+		{"ref", Ref(mustParseList(t, "glob:release/*"), nil), true, decision.Matched},
+		{"no refs", Ref(nil, nil), true, decision.Matched},
+		{"other ref", Ref(mustParseList(t, "master"), nil), false, decision.RefNotIncluded},
+		{"excluded ref", Ref(mustParseList(t, "glob:release/*"), mustParseList(t, "release/v1.0")), false, decision.RefExcluded},
+		{"base ref", BaseRef(mustParseList(t, "main,glob:release/*")), true, decision.Matched},
+		{"no base refs", BaseRef(nil), true, decision.Matched},
+		{"other base ref", BaseRef(mustParseList(t, "glob:release/*")), false, decision.BaseRefNotIncluded},
+		{"head ref", HeadRef(mustParseList(t, "glob:feature/*")), true, decision.Matched},
+		{"no head refs", HeadRef(nil), true, decision.Matched},
+		{"other head ref", HeadRef(mustParseList(t, "glob:fix/*")), false, decision.HeadRefNotIncluded},
+		{"not draft", Draft(false), true, decision.Matched},
+		{"action", Action([]string{"opened", "reopened"}), true, decision.Matched},
+		{"other action", Action([]string{"closed"}), false, decision.ActionNotInList},
+		{"sender", Sender([]string{"octocat"}), true, decision.Matched},
+		{"no senders", Sender(nil), true, decision.Matched},
+		{"other sender", Sender([]string{"dependabot"}), false, decision.SenderNotAllowed},
+		{"all", All(Type(event.Push), Repo([]string{"testing/testing"})), true, decision.Matched},
+		{"all with mismatch", All(Type(event.Push), Repo([]string{"testing/other"}), Action(nil)), false, decision.RepoMismatch},
+		{"any", Any(Repo([]string{"testing/other"}), Sender([]string{"octocat"})), true, decision.Matched},
+		{"any with no match", Any(Repo([]string{"testing/other"}), Sender([]string{"dependabot"})), false, decision.SenderNotAllowed},
+		{"empty any", Any(), false, decision.NotMatched},
+		{"not", Not(Sender([]string{"dependabot"}), decision.SenderNotAllowed), true, decision.Matched},
+		{"not with match", Not(Sender([]string{"octocat"}), decision.SenderNotAllowed), false, decision.SenderNotAllowed},
+	}
+
+	for _, tt := range matcherTests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, reason := tt.matcher.Match(testEvent)
+			if ok != tt.want || reason != tt.reason {
+				t.Fatalf("Match() got %v, %q, wanted %v, %q", ok, reason, tt.want, tt.reason)
+			}
+		})
+	}
+}
+
+func TestDraft(t *testing.T) {
+	draft := &event.Event{Type: event.PullRequest, Draft: true}
+
+	if ok, reason := Draft(false).Match(draft); ok || reason != decision.DraftPullRequest {
+		t.Errorf("Draft(false) got %v, %q, wanted false, %q", ok, reason, decision.DraftPullRequest)
+	}
+	if ok, reason := Draft(true).Match(draft); !ok || reason != decision.Matched {
+		t.Errorf("Draft(true) got %v, %q, wanted true, %q", ok, reason, decision.Matched)
+	}
+}
+
+func TestRefChanges(t *testing.T) {
+	changeTests := []struct {
+		name    string
+		matcher func(bool) Matcher
+		event   *event.Event
+		reason  decision.Reason
+	}{
+		{"created", Created, &event.Event{Type: event.Push, Created: true}, decision.RefCreated},
+		{"deleted", Deleted, &event.Event{Type: event.Push, Deleted: true}, decision.RefDeleted},
+		{"forced", Forced, &event.Event{Type: event.Push, Forced: true}, decision.ForcedPush},
+	}
+
+	for _, tt := range changeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok, reason := tt.matcher(false).Match(tt.event); ok || reason != tt.reason {
+				t.Errorf("Match() excluded got %v, %q, wanted false, %q", ok, reason, tt.reason)
+			}
+			if ok, reason := tt.matcher(true).Match(tt.event); !ok || reason != decision.Matched {
+				t.Errorf("Match() included got %v, %q, wanted true, %q", ok, reason, decision.Matched)
+			}
+			if ok, _ := tt.matcher(false).Match(&event.Event{Type: event.Push}); !ok {
+				t.Error("Match() with an update got false, wanted true")
+			}
+		})
+	}
+}
+
+func TestLabels(t *testing.T) {
+	labelTests := []struct {
+		name    string
+		event   *event.Event
+		include []string
+		exclude []string
+		want    bool
+		reason  decision.Reason
+	}{
+		{"no labels", &event.Event{Action: "opened"}, nil, nil, true, decision.Matched},
+		{"included label", &event.Event{Action: "opened", Labels: []string{"bug", "run-e2e"}}, []string{"run-e2e"}, nil, true, decision.Matched},
+		{"no included label", &event.Event{Action: "opened", Labels: []string{"bug"}}, []string{"run-e2e"}, nil, false, decision.LabelNotIncluded},
+		{"excluded label", &event.Event{Action: "opened", Labels: []string{"run-e2e", "hold"}}, []string{"run-e2e"}, []string{"hold"}, false, decision.LabelExcluded},
+		{"included label added", &event.Event{Action: "labeled", Labels: []string{"run-e2e"}, Label: "run-e2e"}, []string{"run-e2e"}, nil, true, decision.Matched},
+		{"other label added", &event.Event{Action: "labeled", Labels: []string{"run-e2e", "bug"}, Label: "bug"}, []string{"run-e2e"}, nil, false, decision.LabelChangeIgnored},
+		{"excluded label removed", &event.Event{Action: "unlabeled", Labels: []string{"run-e2e"}, Label: "hold"}, []string{"run-e2e"}, []string{"hold"}, true, decision.Matched},
+		{"label added with no labels", &event.Event{Action: "labeled", Labels: []string{"bug"}, Label: "bug"}, nil, nil, true, decision.Matched},
+	}
+
+	for _, tt := range labelTests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, reason := Labels(tt.include, tt.exclude).Match(tt.event)
+			if ok != tt.want || reason != tt.reason {
+				t.Fatalf("Match() got %v, %q, wanted %v, %q", ok, reason, tt.want, tt.reason)
+			}
+		})
+	}
+}
+
+func mustParseList(t *testing.T, s string) pattern.List {
+	t.Helper()
+	l, err := pattern.ParseList(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
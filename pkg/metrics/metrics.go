@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// These are the outcomes of handling a hook.
+const (
+	// Matched is recorded when the hook matched, and the request can
+	// continue.
+	Matched = "matched"
+	// NotMatched is recorded when the hook didn't match.
+	NotMatched = "not-matched"
+	// Error is recorded when handling the hook failed.
+	Error = "error"
+	// Passthrough is recorded when there is no handler for the event, and
+	// the hook is allowed through.
+	Passthrough = "passthrough"
+	// Rejected is recorded when the hook failed signature verification.
+	Rejected = "rejected"
+)
+
+// Metrics records the interception decisions.
+//
+// All methods are safe to call on a nil *Metrics, in which case nothing is
+// recorded.
+type Metrics struct {
+	hooks           *prometheus.CounterVec
+	handlerDuration *prometheus.HistogramVec
+	funcDuration    *prometheus.HistogramVec
+	bodySize        *prometheus.HistogramVec
+}
+
+// New creates and registers the metrics.
+func New(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		hooks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "interceptor_hooks_total",
+			Help: "Count of hooks handled, by provider, event type, repository and outcome.",
+		}, []string{"provider", "event_type", "repo", "outcome"}),
+		handlerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "interceptor_handler_duration_seconds",
+			Help:    "Time taken to handle a hook, including reading the body.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"provider", "event_type", "outcome"}),
+		funcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "interceptor_func_duration_seconds",
+			Help:    "Time taken by the event handler to decide whether a hook matches.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"provider", "event_type"}),
+		bodySize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "interceptor_request_body_bytes",
+			Help:    "Size of the hook request bodies.",
+			Buckets: prometheus.ExponentialBuckets(256, 4, 8),
+		}, []string{"provider", "event_type"}),
+	}
+	reg.MustRegister(m.hooks, m.handlerDuration, m.funcDuration, m.bodySize)
+	return m
+}
+
+// Hook records the outcome of handling a hook, and the time taken.
+func (m *Metrics) Hook(provider, eventType, repo, outcome string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.hooks.WithLabelValues(provider, eventType, repo, outcome).Inc()
+	m.handlerDuration.WithLabelValues(provider, eventType, outcome).Observe(d.Seconds())
+}
+
+// Func records the time taken by an event handler.
+func (m *Metrics) Func(provider, eventType string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.funcDuration.WithLabelValues(provider, eventType).Observe(d.Seconds())
+}
+
+// BodySize records the size of a hook request body.
+func (m *Metrics) BodySize(provider, eventType string, size int) {
+	if m == nil {
+		return
+	}
+	m.bodySize.WithLabelValues(provider, eventType).Observe(float64(size))
+}
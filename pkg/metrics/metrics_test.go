@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestHook(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := New(reg)
+
+	m.Hook("github", "push", "testing/testing", Matched, time.Millisecond)
+	m.Hook("github", "push", "testing/testing", Matched, time.Millisecond)
+	m.Hook("github", "push", "testing/testing", NotMatched, time.Millisecond)
+
+	want := `
+# HELP interceptor_hooks_total Count of hooks handled, by provider, event type, repository and outcome.
+# TYPE interceptor_hooks_total counter
+interceptor_hooks_total{event_type="push",outcome="matched",provider="github",repo="testing/testing"} 2
+interceptor_hooks_total{event_type="push",outcome="not-matched",provider="github",repo="testing/testing"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "interceptor_hooks_total"); err != nil {
+		t.Fatal(err)
+	}
+	if c := testutil.CollectAndCount(m.handlerDuration); c != 2 {
+		t.Fatalf("handler duration got %d series, wanted 2", c)
+	}
+}
+
+func TestFuncAndBodySize(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := New(reg)
+
+	m.Func("gitlab", "Push Hook", time.Millisecond)
+	m.BodySize("gitlab", "Push Hook", 1024)
+
+	if c := testutil.CollectAndCount(m.funcDuration); c != 1 {
+		t.Fatalf("func duration got %d series, wanted 1", c)
+	}
+	if c := testutil.CollectAndCount(m.bodySize); c != 1 {
+		t.Fatalf("body size got %d series, wanted 1", c)
+	}
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+
+	m.Hook("github", "push", "testing/testing", Matched, time.Millisecond)
+	m.Func("github", "push", time.Millisecond)
+	m.BodySize("github", "push", 1024)
+}
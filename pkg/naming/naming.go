@@ -0,0 +1,110 @@
+// Package naming generates names that are valid Kubernetes resource names
+// and label values.
+package naming
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bigkevmcd/interceptor/pkg/event"
+)
+
+const (
+	// MaxLength is the maximum length of a DNS-1123 label, and of a label
+	// value.
+	MaxLength = 63
+
+	// hashLength is the length of the hash that is appended to truncated
+	// names.
+	hashLength = 8
+)
+
+var (
+	invalidLabelRE      = regexp.MustCompile("[^a-z0-9-]+")
+	invalidLabelValueRE = regexp.MustCompile("[^a-z0-9-_.]+")
+)
+
+// DNS1123Label converts a string to a valid DNS-1123 label, e.g. for
+// Kubernetes resource names.
+//
+// The string is lowercased, runs of invalid characters are replaced with
+// "-", and leading and trailing "-" are removed. If the result is longer
+// than 63 characters, it's truncated, and a hash of the original string is
+// appended, so that long names with the same prefix don't collide.
+//
+// Strings with no valid characters are converted to the hash, an empty
+// string is returned unchanged.
+func DNS1123Label(s string) string {
+	return sanitise(s, invalidLabelRE)
+}
+
+// LabelValue converts a string to a valid Kubernetes label value.
+//
+// This is the same as DNS1123Label, but "_" and "." are also allowed, as
+// long as they're not at the start or end of the value.
+func LabelValue(s string) string {
+	return sanitise(s, invalidLabelValueRE)
+}
+
+// Values returns names derived from an event that are added to
+// "intercepted":
+//
+//    repo_name and repo_label - from the full name of the repository
+//    branch_name and branch_label - from the branch for pushes, or the head
+//    branch for pull requests, these are not added for tag pushes
+//    pr_name - from the repository and pull request number e.g.
+//    "bigkevmcd-interceptor-pr-12", only for pull requests
+//
+// The _name values are DNS-1123 labels, and the _label values are label
+// values.
+func Values(e *event.Event) map[string]interface{} {
+	values := map[string]interface{}{
+		"repo_name":  DNS1123Label(e.Repo),
+		"repo_label": LabelValue(e.Repo),
+	}
+	if branch := e.BranchName(); branch != "" {
+		values["branch_name"] = DNS1123Label(branch)
+		values["branch_label"] = LabelValue(branch)
+	}
+	if e.Type == event.PullRequest {
+		values["pr_name"] = DNS1123Label(e.Repo + "-pr-" + strconv.Itoa(e.Number))
+	}
+	return values
+}
+
+// AddValues adds the Values for the event to the intercepted values.
+func AddValues(intercepted map[string]interface{}, e *event.Event) {
+	for k, v := range Values(e) {
+		intercepted[k] = v
+	}
+}
+
+func sanitise(s string, invalid *regexp.Regexp) string {
+	if s == "" {
+		return ""
+	}
+	name := trim(invalid.ReplaceAllString(strings.ToLower(s), "-"))
+	if name == "" {
+		return hash(s)
+	}
+	if len(name) <= MaxLength {
+		return name
+	}
+	return trim(name[:MaxLength-hashLength-1]) + "-" + hash(s)
+}
+
+// trim removes the leading and trailing characters that are not
+// alphanumeric.
+func trim(s string) string {
+	return strings.TrimFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+}
+
+func hash(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])[:hashLength]
+}
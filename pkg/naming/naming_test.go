@@ -0,0 +1,130 @@
+package naming
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/bigkevmcd/interceptor/pkg/event"
+)
+
+var (
+	dns1123LabelRE = regexp.MustCompile("^[a-z0-9]([-a-z0-9]*[a-z0-9])?$")
+	labelValueRE   = regexp.MustCompile("^[a-z0-9]([-a-z0-9_.]*[a-z0-9])?$")
+)
+
+func TestDNS1123Label(t *testing.T) {
+	long := strings.Repeat("a", 70)
+	nameTests := []struct {
+		s    string
+		want string
+	}{
+		{"main", "main"},
+		{"feature/ABC_123", "feature-abc-123"},
+		{"--release--", "release"},
+		{"a..b", "a-b"},
+		{"bigkevmcd/interceptor", "bigkevmcd-interceptor"},
+		{"", ""},
+		{"___", hash("___")},
+		{long, strings.Repeat("a", 54) + "-" + hash(long)},
+		{strings.Repeat("a", 53) + "/b" + long, strings.Repeat("a", 53) + "-" + hash(strings.Repeat("a", 53)+"/b"+long)},
+	}
+
+	for _, tt := range nameTests {
+		got := DNS1123Label(tt.s)
+		if got != tt.want {
+			t.Errorf("DNS1123Label(%q) got %q, wanted %q", tt.s, got, tt.want)
+		}
+		if tt.s != "" && !dns1123LabelRE.MatchString(got) || len(got) > MaxLength {
+			t.Errorf("DNS1123Label(%q) got %q, which is not a valid DNS-1123 label", tt.s, got)
+		}
+	}
+}
+
+func TestDNS1123LabelWithLongNames(t *testing.T) {
+	prefix := strings.Repeat("feature-", 10)
+	a, b := DNS1123Label(prefix+"one"), DNS1123Label(prefix+"two")
+	if a == b {
+		t.Fatalf("DNS1123Label() got %q for different names", a)
+	}
+}
+
+func TestLabelValue(t *testing.T) {
+	long := strings.Repeat("a", 70)
+	valueTests := []struct {
+		s    string
+		want string
+	}{
+		{"main", "main"},
+		{"feature/ABC_123", "feature-abc_123"},
+		{"v1.0.0", "v1.0.0"},
+		{"_release.", "release"},
+		{"", ""},
+		{long, strings.Repeat("a", 54) + "-" + hash(long)},
+	}
+
+	for _, tt := range valueTests {
+		got := LabelValue(tt.s)
+		if got != tt.want {
+			t.Errorf("LabelValue(%q) got %q, wanted %q", tt.s, got, tt.want)
+		}
+		if tt.s != "" && !labelValueRE.MatchString(got) || len(got) > MaxLength {
+			t.Errorf("LabelValue(%q) got %q, which is not a valid label value", tt.s, got)
+		}
+	}
+}
+
+func TestValues(t *testing.T) {
+	valueTests := []struct {
+		name  string
+		event *event.Event
+		want  map[string]interface{}
+	}{
+		{"push", &event.Event{Type: event.Push, Repo: "bigkevmcd/interceptor", Ref: "feature/ABC_123"},
+			map[string]interface{}{
+				"repo_name":    "bigkevmcd-interceptor",
+				"repo_label":   "bigkevmcd-interceptor",
+				"branch_name":  "feature-abc-123",
+				"branch_label": "feature-abc_123",
+			}},
+		{"tag push", &event.Event{Type: event.Push, Repo: "bigkevmcd/interceptor", Ref: "refs/tags/v1.0.0"},
+			map[string]interface{}{
+				"repo_name":  "bigkevmcd-interceptor",
+				"repo_label": "bigkevmcd-interceptor",
+			}},
+		{"pull request", &event.Event{Type: event.PullRequest, Repo: "bigkevmcd/interceptor", HeadRef: "fix/Typo", Number: 12},
+			map[string]interface{}{
+				"repo_name":    "bigkevmcd-interceptor",
+				"repo_label":   "bigkevmcd-interceptor",
+				"branch_name":  "fix-typo",
+				"branch_label": "fix-typo",
+				"pr_name":      "bigkevmcd-interceptor-pr-12",
+			}},
+	}
+
+	for _, tt := range valueTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Values(tt.event); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Values() got %#v, wanted %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzDNS1123Label(f *testing.F) {
+	for _, s := range []string{"main", "feature/ABC_123", "___", strings.Repeat("a-", 40)} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if s == "" {
+			return
+		}
+		if got := DNS1123Label(s); !dns1123LabelRE.MatchString(got) || len(got) > MaxLength {
+			t.Fatalf("DNS1123Label(%q) got %q, which is not a valid DNS-1123 label", s, got)
+		}
+		if got := LabelValue(s); !labelValueRE.MatchString(got) || len(got) > MaxLength {
+			t.Fatalf("LabelValue(%q) got %q, which is not a valid label value", s, got)
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package pattern
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	globPrefix  = "glob:"
+	regexPrefix = "regex:"
+)
+
+// Pattern matches strings.
+type Pattern interface {
+	Match(s string) bool
+	String() string
+}
+
+// Parse parses a pattern, the syntax is selected with a prefix.
+//
+//    "glob:release/*" is a glob pattern, "*" matches any characters except
+//    "/", "**" matches any characters including "/", and "?" matches any
+//    single character except "/".
+//    "regex:^release/v[0-9]+$" is a regular expression, this is not
+//    anchored, so use "^" and "$" to match the whole string.
+//
+// Anything else is matched exactly.
+func Parse(s string) (Pattern, error) {
+	switch {
+	case strings.HasPrefix(s, globPrefix):
+		return parseGlob(strings.TrimPrefix(s, globPrefix))
+	case strings.HasPrefix(s, regexPrefix):
+		re, err := regexp.Compile(strings.TrimPrefix(s, regexPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", s, err)
+		}
+		return regexPattern{source: s, re: re}, nil
+	}
+	return exactPattern(s), nil
+}
+
+// List is a set of patterns, it matches if any of the patterns match.
+type List []Pattern
+
+// ParseList parses a comma-separated list of patterns, see Split for how the
+// list is split.
+//
+// Whitespace around the patterns, and empty patterns are ignored, so an
+// empty string is an empty list.
+func ParseList(s string) (List, error) {
+	return parseList(s, Parse)
+}
+
+// ParseGlobList parses a comma-separated list of patterns, where patterns
+// without a prefix are globs rather than exact matches.
+//
+// This is useful for lists of file paths, where globs are more natural.
+func ParseGlobList(s string) (List, error) {
+	return parseList(s, func(v string) (Pattern, error) {
+		if !strings.HasPrefix(v, globPrefix) && !strings.HasPrefix(v, regexPrefix) {
+			v = globPrefix + v
+		}
+		return Parse(v)
+	})
+}
+
+// parseList parses each of the patterns in a comma-separated list with the
+// parse function, ignoring whitespace and empty patterns.
+func parseList(s string, parse func(string) (Pattern, error)) (List, error) {
+	l := List{}
+	for _, v := range Split(s) {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		p, err := parse(v)
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, p)
+	}
+	return l, nil
+}
+
+// Split splits a comma-separated list of patterns.
+//
+// Regular expressions can contain commas, e.g. "regex:^v[0-9]{1,3}$", so in
+// "regex:" patterns, commas inside brackets, braces and parentheses, or
+// escaped as "\,", don't separate patterns. Exact and glob patterns can't
+// contain commas.
+func Split(s string) []string {
+	var items []string
+	start, depth, inClass := 0, 0, false
+	regex := isRegex(s)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !regex {
+			if c == ',' {
+				items = append(items, s[start:i])
+				start = i + 1
+				regex = isRegex(s[start:])
+			}
+			continue
+		}
+		switch {
+		case c == '\\':
+			i++
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+		case c == '(' || c == '{':
+			depth++
+		case (c == ')' || c == '}') && depth > 0:
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, s[start:i])
+			start = i + 1
+			regex = isRegex(s[start:])
+		}
+	}
+	return append(items, s[start:])
+}
+
+func isRegex(s string) bool {
+	return strings.HasPrefix(strings.TrimSpace(s), regexPrefix)
+}
+
+// Match returns true if any of the patterns in the list match.
+func (l List) Match(s string) bool {
+	for _, p := range l {
+		if p.Match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Empty returns true if there are no patterns in the list.
+func (l List) Empty() bool {
+	return len(l) == 0
+}
+
+type exactPattern string
+
+func (p exactPattern) Match(s string) bool {
+	return string(p) == s
+}
+
+func (p exactPattern) String() string {
+	return string(p)
+}
+
+type regexPattern struct {
+	source string
+	re     *regexp.Regexp
+}
+
+func (p regexPattern) Match(s string) bool {
+	return p.re.MatchString(s)
+}
+
+func (p regexPattern) String() string {
+	return p.source
+}
+
+func parseGlob(glob string) (Pattern, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	runes := []rune(glob)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				i++
+				// "**/" matches zero or more directories.
+				if i+1 < len(runes) && runes[i+1] == '/' {
+					i++
+					sb.WriteString("(?:.*/)?")
+					continue
+				}
+				sb.WriteString(".*")
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", globPrefix+glob, err)
+	}
+	return regexPattern{source: globPrefix + glob, re: re}, nil
+}
@@ -0,0 +1,143 @@
+package pattern
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	matchTests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"master", "master", true},
+		{"master", "master2", false},
+		{"release/*", "release/*", true},
+		{"release/*", "release/v1", false},
+		{"glob:release/*", "release/v1", true},
+		{"glob:release/*", "release/v1/fix", false},
+		{"glob:release/*", "release", false},
+		{"glob:dependabot/**", "dependabot/npm/lodash", true},
+		{"glob:dependabot/**", "dependabot/npm", true},
+		{"glob:**/*.go", "main.go", true},
+		{"glob:**/*.go", "pkg/git/utils.go", true},
+		{"glob:**/*.go", "pkg/git/utils.yaml", false},
+		{"glob:docs/**/*.md", "docs/README.md", true},
+		{"glob:v?.0", "v1.0", true},
+		{"glob:v?.0", "v10.0", false},
+		{"glob:feature.+", "feature.+", true},
+		{"glob:feature.+", "featureX+", false},
+		{"glob:feature/café-*", "feature/café-1", true},
+		{"glob:feature/caf?-1", "feature/café-1", true},
+		{"glob:feature/café-*", "feature/cafe-1", false},
+		{"regex:^release/v[0-9]+$", "release/v12", true},
+		{"regex:^release/v[0-9]+$", "release/v12-rc", false},
+		{"regex:fix", "bugfix/123", true},
+	}
+
+	for _, tt := range matchTests {
+		p, err := Parse(tt.pattern)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %s", tt.pattern, err)
+			continue
+		}
+		if m := p.Match(tt.s); m != tt.want {
+			t.Errorf("Parse(%q).Match(%q) got %v, wanted %v", tt.pattern, tt.s, m, tt.want)
+		}
+		if p.String() != tt.pattern {
+			t.Errorf("Parse(%q).String() got %q", tt.pattern, p.String())
+		}
+	}
+}
+
+func TestParseWithInvalidRegex(t *testing.T) {
+	_, err := Parse("regex:release/(")
+	if err == nil {
+		t.Fatal("expected an error parsing an invalid regex")
+	}
+}
+
+func TestParseList(t *testing.T) {
+	listTests := []struct {
+		patterns string
+		s        string
+		want     bool
+	}{
+		{"", "master", false},
+		{"master", "master", true},
+		{"master, develop", "develop", true},
+		{"master,glob:release/*", "release/v1", true},
+		{"master,glob:release/*", "feature/v1", false},
+		{"regex:^v[0-9]{1,3}$", "v12", true},
+		{"regex:^v[0-9]{1,3}$,main", "main", true},
+		{"regex:^v[0-9]{1,3}$,main", "v1234", false},
+	}
+
+	for _, tt := range listTests {
+		l, err := ParseList(tt.patterns)
+		if err != nil {
+			t.Errorf("ParseList(%q) failed: %s", tt.patterns, err)
+			continue
+		}
+		if m := l.Match(tt.s); m != tt.want {
+			t.Errorf("ParseList(%q).Match(%q) got %v, wanted %v", tt.patterns, tt.s, m, tt.want)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	splitTests := []struct {
+		s    string
+		want []string
+	}{
+		{"", []string{""}},
+		{"master, develop", []string{"master", " develop"}},
+		{"regex:^v[0-9]{1,3}$,main", []string{"regex:^v[0-9]{1,3}$", "main"}},
+		{"main,regex:^(a,b)$", []string{"main", "regex:^(a,b)$"}},
+		{"regex:^[,(]$,main", []string{"regex:^[,(]$", "main"}},
+		{`regex:^a\,b$,main`, []string{`regex:^a\,b$`, "main"}},
+		{"glob:{a,b},main", []string{"glob:{a", "b}", "main"}},
+	}
+
+	for _, tt := range splitTests {
+		if got := Split(tt.s); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Split(%q) got %#v, wanted %#v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestListEmpty(t *testing.T) {
+	l, err := ParseList(" , ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !l.Empty() {
+		t.Fatalf("ParseList() got %v, wanted an empty list", l)
+	}
+}
+
+func TestParseGlobList(t *testing.T) {
+	listTests := []struct {
+		patterns string
+		s        string
+		want     bool
+	}{
+		{"docs/**", "docs/README.md", true},
+		{"docs/**", "pkg/git/utils.go", false},
+		{"**/*.go, Dockerfile", "Dockerfile", true},
+		{"regex:\\.go$", "pkg/git/utils.go", true},
+		{"glob:*.md", "README.md", true},
+	}
+
+	for _, tt := range listTests {
+		l, err := ParseGlobList(tt.patterns)
+		if err != nil {
+			t.Errorf("ParseGlobList(%q) failed: %s", tt.patterns, err)
+			continue
+		}
+		if m := l.Match(tt.s); m != tt.want {
+			t.Errorf("ParseGlobList(%q).Match(%q) got %v, wanted %v", tt.patterns, tt.s, m, tt.want)
+		}
+	}
+}
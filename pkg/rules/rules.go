@@ -0,0 +1,397 @@
+package rules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/expression"
+	"github.com/bigkevmcd/interceptor/pkg/forks"
+	"github.com/bigkevmcd/interceptor/pkg/pattern"
+	"github.com/bigkevmcd/interceptor/pkg/templates"
+)
+
+const (
+	// PushEvent is the event for rules that match pushes.
+	PushEvent = "push"
+	// PullRequestEvent is the event for rules that match pull requests.
+	PullRequestEvent = "pull_request"
+)
+
+var nameRE = regexp.MustCompile("^[a-z0-9]([-a-z0-9]*[a-z0-9])?$")
+
+// Config is the top-level of the rules file.
+type Config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule is a named set of conditions that a hook must match, these are
+// equivalent to the headers that can be configured on a trigger.
+//
+//    name - the rule is addressable as /rules/<name>
+//    event - either "push" or "pull_request"
+//    repos - the full names of the repositories to match
+//    refs - patterns for the branches to match (push only)
+//    excludeRefs - patterns for the branches to exclude (push only)
+//    paths - globs for the changed files to match (push only)
+//    excludePaths - globs for the changed files to exclude (push only)
+//    actions - the pull request actions to match (pull_request only)
+//    baseRefs - patterns for the branches pull requests will be merged into
+//    (pull_request only)
+//    headRefs - patterns for the branches with the pull request changes
+//    (pull_request only)
+//    includeDrafts - draft pull requests only match if this is true
+//    (pull_request only)
+//    labels - the pull request must have one of these labels (pull_request
+//    only)
+//    excludeLabels - the pull request must have none of these labels
+//    (pull_request only)
+//    forkPolicy - "allow", "reject" or "ok-to-test", what happens to pull
+//    requests from forks opened by untrusted users (pull_request only)
+//    senders - the logins of the users whose events match, all users match
+//    if this is empty
+//    skipMarkers - markers like "[skip ci]" that skip the hook if they're in
+//    the commit message, these replace the default markers
+//    skipAllCommits - check the messages of all the commits in a push for
+//    skip markers, not just the head commit (push only)
+//    skipDisabled - hooks are never skipped because of markers if this is
+//    true
+//    filter - a CEL expression that must return true for the hook to match
+//    overlays - CEL expressions for values that are added to "intercepted"
+//    templates - Go templates for values that are added to "intercepted"
+//    outputKey - the key that the intercepted values are added to the body
+//    as, instead of "intercepted"
+//    enrichment - static values that are added to "intercepted", the keys
+//    must be valid output keys
+type Rule struct {
+	Name           string            `json:"name"`
+	Event          string            `json:"event"`
+	Repos          []string          `json:"repos"`
+	Refs           []string          `json:"refs,omitempty"`
+	ExcludeRefs    []string          `json:"excludeRefs,omitempty"`
+	Paths          []string          `json:"paths,omitempty"`
+	ExcludePaths   []string          `json:"excludePaths,omitempty"`
+	Actions        []string          `json:"actions,omitempty"`
+	BaseRefs       []string          `json:"baseRefs,omitempty"`
+	HeadRefs       []string          `json:"headRefs,omitempty"`
+	IncludeDrafts  bool              `json:"includeDrafts,omitempty"`
+	Labels         []string          `json:"labels,omitempty"`
+	ExcludeLabels  []string          `json:"excludeLabels,omitempty"`
+	ForkPolicy     string            `json:"forkPolicy,omitempty"`
+	Senders        []string          `json:"senders,omitempty"`
+	SkipMarkers    []string          `json:"skipMarkers,omitempty"`
+	SkipAllCommits bool              `json:"skipAllCommits,omitempty"`
+	SkipDisabled   bool              `json:"skipDisabled,omitempty"`
+	Filter         string            `json:"filter,omitempty"`
+	Overlays       map[string]string `json:"overlays,omitempty"`
+	Templates      map[string]string `json:"templates,omitempty"`
+	OutputKey      string            `json:"outputKey,omitempty"`
+	Enrichment     map[string]string `json:"enrichment,omitempty"`
+}
+
+// RuleSet is a validated set of rules, indexed by name.
+type RuleSet struct {
+	version string
+	rules   map[string]*Rule
+}
+
+// Source provides the current set of rules.
+type Source interface {
+	RuleSet() *RuleSet
+}
+
+// RuleSet implements the Source interface, for a fixed set of rules.
+func (s *RuleSet) RuleSet() *RuleSet {
+	return s
+}
+
+// Version identifies the content that the rules were parsed from.
+func (s *RuleSet) Version() string {
+	return s.version
+}
+
+// Get returns the named rule, or nil if there is no rule with that name.
+func (s *RuleSet) Get(name string) *Rule {
+	return s.rules[name]
+}
+
+// Len returns the number of rules in the set.
+func (s *RuleSet) Len() int {
+	return len(s.rules)
+}
+
+// LoadFile parses and validates the rules in a file.
+func LoadFile(path string) (*RuleSet, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file %s: %w", path, err)
+	}
+	rs, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules file %s: %w", path, err)
+	}
+	return rs, nil
+}
+
+// Parse parses and validates rules from YAML.
+func Parse(b []byte) (*RuleSet, error) {
+	var c Config
+	if err := yaml.UnmarshalStrict(b, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	rs := &RuleSet{version: contentVersion(b), rules: map[string]*Rule{}}
+	for i := range c.Rules {
+		rs.rules[c.Rules[i].Name] = &c.Rules[i]
+	}
+	return rs, nil
+}
+
+// contentVersion returns a short hash of the content.
+func contentVersion(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])[:12]
+}
+
+// Names returns the sorted names of the rules in the set.
+func (s *RuleSet) Names() []string {
+	names := []string{}
+	for k := range s.rules {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that the rules are valid, and returns an error describing
+// the first invalid rule.
+func (c *Config) Validate() error {
+	seen := map[string]int{}
+	for i, r := range c.Rules {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("rules[%d]: %w", i, err)
+		}
+		if prev, ok := seen[r.Name]; ok {
+			return fmt.Errorf("rules[%d]: duplicate name %q, also used by rules[%d]", i, r.Name, prev)
+		}
+		seen[r.Name] = i
+	}
+	return nil
+}
+
+// Validate checks that the rule is valid.
+func (r *Rule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name: must not be empty")
+	}
+	if !nameRE.MatchString(r.Name) {
+		return fmt.Errorf("name: %q must consist of lowercase alphanumeric characters or '-'", r.Name)
+	}
+	if len(r.Repos) == 0 {
+		return fmt.Errorf("%s: repos: must have at least one repo", r.Name)
+	}
+	for j, repo := range r.Repos {
+		if strings.TrimSpace(repo) == "" || strings.Contains(repo, ",") {
+			return fmt.Errorf("%s: repos[%d]: invalid repo %q", r.Name, j, repo)
+		}
+	}
+	switch r.Event {
+	case PushEvent:
+		pullRequestFields := []struct {
+			name   string
+			values []string
+		}{
+			{"actions", r.Actions}, {"baseRefs", r.BaseRefs}, {"headRefs", r.HeadRefs},
+			{"labels", r.Labels}, {"excludeLabels", r.ExcludeLabels},
+		}
+		for _, f := range pullRequestFields {
+			if len(f.values) > 0 {
+				return fmt.Errorf("%s: %s: not supported for %q rules", r.Name, f.name, r.Event)
+			}
+		}
+		if r.IncludeDrafts {
+			return fmt.Errorf("%s: includeDrafts: not supported for %q rules", r.Name, r.Event)
+		}
+		if r.ForkPolicy != "" {
+			return fmt.Errorf("%s: forkPolicy: not supported for %q rules", r.Name, r.Event)
+		}
+	case PullRequestEvent:
+		if len(r.Actions) == 0 {
+			return fmt.Errorf("%s: actions: must have at least one action for %q rules", r.Name, r.Event)
+		}
+		pushFields := []struct {
+			name   string
+			values []string
+		}{
+			{"refs", r.Refs}, {"excludeRefs", r.ExcludeRefs}, {"paths", r.Paths}, {"excludePaths", r.ExcludePaths},
+		}
+		for _, f := range pushFields {
+			if len(f.values) > 0 {
+				return fmt.Errorf("%s: %s: not supported for %q rules", r.Name, f.name, r.Event)
+			}
+		}
+		if r.SkipAllCommits {
+			return fmt.Errorf("%s: skipAllCommits: not supported for %q rules", r.Name, r.Event)
+		}
+	case "":
+		return fmt.Errorf("%s: event: must not be empty", r.Name)
+	default:
+		return fmt.Errorf("%s: event: unknown event %q, must be one of %q or %q", r.Name, r.Event, PushEvent, PullRequestEvent)
+	}
+	if err := validatePatterns(r.Name, "refs", r.Refs, pattern.Parse); err != nil {
+		return err
+	}
+	if err := validatePatterns(r.Name, "excludeRefs", r.ExcludeRefs, pattern.Parse); err != nil {
+		return err
+	}
+	if err := validatePatterns(r.Name, "baseRefs", r.BaseRefs, pattern.Parse); err != nil {
+		return err
+	}
+	if err := validatePatterns(r.Name, "headRefs", r.HeadRefs, pattern.Parse); err != nil {
+		return err
+	}
+	for j, marker := range r.SkipMarkers {
+		if strings.TrimSpace(marker) == "" || strings.Contains(marker, ",") {
+			return fmt.Errorf("%s: skipMarkers[%d]: invalid marker %q", r.Name, j, marker)
+		}
+	}
+	if _, err := forks.ParsePolicy(r.ForkPolicy); err != nil {
+		return fmt.Errorf("%s: forkPolicy: %w", r.Name, err)
+	}
+	if err := validatePatterns(r.Name, "paths", r.Paths, parseGlob); err != nil {
+		return err
+	}
+	if err := validatePatterns(r.Name, "excludePaths", r.ExcludePaths, parseGlob); err != nil {
+		return err
+	}
+	return r.validateExpressions()
+}
+
+func (r *Rule) validateExpressions() error {
+	e, err := expression.New()
+	if err != nil {
+		return err
+	}
+	if r.Filter != "" {
+		if err := e.Check(r.Filter); err != nil {
+			return fmt.Errorf("%s: filter: %w", r.Name, err)
+		}
+	}
+	for k, v := range r.Overlays {
+		if strings.TrimSpace(k) == "" || strings.Contains(k, "=") {
+			return fmt.Errorf("%s: overlays: invalid key %q", r.Name, k)
+		}
+		if err := e.Check(v); err != nil {
+			return fmt.Errorf("%s: overlays[%s]: %w", r.Name, k, err)
+		}
+	}
+	for k, v := range r.Templates {
+		if strings.TrimSpace(k) == "" || strings.Contains(k, "=") {
+			return fmt.Errorf("%s: templates: invalid key %q", r.Name, k)
+		}
+		if err := templates.Check(v); err != nil {
+			return fmt.Errorf("%s: templates[%s]: %w", r.Name, k, err)
+		}
+	}
+	for k := range r.Enrichment {
+		if !decision.ValidKey(k) {
+			return fmt.Errorf("%s: enrichment: invalid key %q", r.Name, k)
+		}
+	}
+	if r.OutputKey != "" && !decision.ValidKey(r.OutputKey) {
+		return fmt.Errorf("%s: outputKey: invalid key %q", r.Name, r.OutputKey)
+	}
+	return nil
+}
+
+// Headers returns the headers that configure the interceptor handlers to
+// match the rule.
+func (r *Rule) Headers() http.Header {
+	h := http.Header{}
+	set := func(k string, v []string) {
+		if len(v) > 0 {
+			h.Set(k, strings.Join(v, ","))
+		}
+	}
+	switch r.Event {
+	case PushEvent:
+		set("Push-Repo", r.Repos)
+		set("Push-Ref", r.Refs)
+		set("PushExclude-Ref", r.ExcludeRefs)
+		set("Push-Paths", r.Paths)
+		set("Push-Exclude-Paths", r.ExcludePaths)
+		set("Push-Sender", r.Senders)
+	case PullRequestEvent:
+		set("Pullrequest-Repo", r.Repos)
+		set("Pullrequest-Action", r.Actions)
+		set("Pullrequest-Sender", r.Senders)
+		set("Pullrequest-Base-Ref", r.BaseRefs)
+		set("Pullrequest-Head-Ref", r.HeadRefs)
+		set("Pullrequest-Labels", r.Labels)
+		set("Pullrequest-Exclude-Labels", r.ExcludeLabels)
+		if r.IncludeDrafts {
+			h.Set("Pullrequest-Include-Drafts", "true")
+		}
+		if r.ForkPolicy != "" {
+			h.Set("Pullrequest-Fork-Policy", r.ForkPolicy)
+		}
+	}
+	set("Interceptor-Skip-Markers", r.SkipMarkers)
+	if r.SkipAllCommits {
+		h.Set("Interceptor-Skip-All-Commits", "true")
+	}
+	if r.SkipDisabled {
+		h.Set("Interceptor-Skip-Disabled", "true")
+	}
+	if r.Filter != "" {
+		h.Set("Interceptor-Filter", r.Filter)
+	}
+	addSorted(h, "Interceptor-Overlay", r.Overlays)
+	addSorted(h, "Interceptor-Template", r.Templates)
+	if r.OutputKey != "" {
+		h.Set("Interceptor-Output-Key", r.OutputKey)
+	}
+	return h
+}
+
+// addSorted adds a key=value header for each of the values, sorted by key.
+func addSorted(h http.Header, name string, values map[string]string) {
+	keys := []string{}
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		h.Add(name, k+"="+values[k])
+	}
+}
+
+func parseGlob(s string) (pattern.Pattern, error) {
+	l, err := pattern.ParseGlobList(s)
+	if err != nil {
+		return nil, err
+	}
+	return l[0], nil
+}
+
+func validatePatterns(name, field string, patterns []string, parse func(string) (pattern.Pattern, error)) error {
+	for j, p := range patterns {
+		if strings.TrimSpace(p) == "" || len(pattern.Split(p)) != 1 {
+			return fmt.Errorf("%s: %s[%d]: invalid pattern %q", name, field, j, p)
+		}
+		if _, err := parse(p); err != nil {
+			return fmt.Errorf("%s: %s[%d]: %w", name, field, j, err)
+		}
+	}
+	return nil
+}
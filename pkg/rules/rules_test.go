@@ -0,0 +1,211 @@
+package rules
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testRules = `
+rules:
+  - name: dev-ci
+    event: push
+    repos:
+      - testing/repo1
+      - testing/repo2
+    refs:
+      - master
+      - glob:release/*
+    excludeRefs:
+      - release/broken
+    paths:
+      - services/api/**
+    skipMarkers:
+      - "[skip dev]"
+    skipAllCommits: true
+    enrichment:
+      environment: dev
+  - name: pr-ci
+    event: pull_request
+    repos:
+      - testing/repo1
+    actions:
+      - opened
+      - synchronize
+    senders:
+      - octocat
+    baseRefs:
+      - main
+      - glob:release/*
+    includeDrafts: true
+    labels:
+      - run-e2e
+    forkPolicy: ok-to-test
+    skipDisabled: true
+    filter: "!body.pull_request.draft"
+    overlays:
+      number: body.pull_request.number
+      base: body.pull_request.base.ref
+    templates:
+      name: "pr-{{ .Number }}"
+      image_tag: "{{ .Repo | dns1123 }}-{{ .ShortSHA }}"
+    outputKey: build
+`
+
+func TestParse(t *testing.T) {
+	rs, err := Parse([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rs.Len() != 2 {
+		t.Fatalf("Parse() got %d rules, wanted 2", rs.Len())
+	}
+	want := &Rule{
+		Name:           "dev-ci",
+		Event:          PushEvent,
+		Repos:          []string{"testing/repo1", "testing/repo2"},
+		Refs:           []string{"master", "glob:release/*"},
+		ExcludeRefs:    []string{"release/broken"},
+		Paths:          []string{"services/api/**"},
+		SkipMarkers:    []string{"[skip dev]"},
+		SkipAllCommits: true,
+		Enrichment:     map[string]string{"environment": "dev"},
+	}
+	if r := rs.Get("dev-ci"); !reflect.DeepEqual(r, want) {
+		t.Fatalf("Get() got %#v, wanted %#v", r, want)
+	}
+	if r := rs.Get("unknown"); r != nil {
+		t.Fatalf("Get() got %#v, wanted nil", r)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	errorTests := []struct {
+		name    string
+		rules   string
+		wantErr string
+	}{
+		{"unknown field", "rules:\n  - name: test\n    evnt: push\n", `unknown field "evnt"`},
+		{"missing name", "rules:\n  - event: push\n    repos: [a/b]\n", "rules[0]: name: must not be empty"},
+		{"invalid name", "rules:\n  - name: Dev_CI\n    event: push\n    repos: [a/b]\n", `rules[0]: name: "Dev_CI" must consist of`},
+		{"no repos", "rules:\n  - name: test\n    event: push\n", "rules[0]: test: repos: must have at least one repo"},
+		{"no event", "rules:\n  - name: test\n    repos: [a/b]\n", "rules[0]: test: event: must not be empty"},
+		{"unknown event", "rules:\n  - name: test\n    event: pushh\n    repos: [a/b]\n", `rules[0]: test: event: unknown event "pushh"`},
+		{"push actions", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    actions: [opened]\n", `rules[0]: test: actions: not supported for "push" rules`},
+		{"push base refs", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    baseRefs: [main]\n", `rules[0]: test: baseRefs: not supported for "push" rules`},
+		{"push include drafts", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    includeDrafts: true\n", `rules[0]: test: includeDrafts: not supported for "push" rules`},
+		{"invalid head ref", "rules:\n  - name: test\n    event: pull_request\n    repos: [a/b]\n    actions: [opened]\n    headRefs: [\"regex:(\"]\n", "rules[0]: test: headRefs[0]: invalid pattern"},
+		{"no actions", "rules:\n  - name: test\n    event: pull_request\n    repos: [a/b]\n", `rules[0]: test: actions: must have at least one action`},
+		{"push fork policy", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    forkPolicy: reject\n", `rules[0]: test: forkPolicy: not supported for "push" rules`},
+		{"invalid fork policy", "rules:\n  - name: test\n    event: pull_request\n    repos: [a/b]\n    actions: [opened]\n    forkPolicy: never\n", `rules[0]: test: forkPolicy: unknown fork policy "never"`},
+		{"pull_request refs", "rules:\n  - name: test\n    event: pull_request\n    repos: [a/b]\n    actions: [opened]\n    refs: [master]\n", `rules[0]: test: refs: not supported for "pull_request" rules`},
+		{"invalid ref", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    refs: [master, \"regex:(\"]\n", "rules[0]: test: refs[1]: invalid pattern"},
+		{"invalid path", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    paths: [\"a,b\"]\n", `rules[0]: test: paths[0]: invalid pattern "a,b"`},
+		{"pull_request skip all commits", "rules:\n  - name: test\n    event: pull_request\n    repos: [a/b]\n    actions: [opened]\n    skipAllCommits: true\n", `rules[0]: test: skipAllCommits: not supported for "pull_request" rules`},
+		{"invalid skip marker", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    skipMarkers: [\"[skip,ci]\"]\n", `rules[0]: test: skipMarkers[0]: invalid marker "[skip,ci]"`},
+		{"invalid filter", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    filter: \"body.ref ==\"\n", "rules[0]: test: filter: failed to compile expression"},
+		{"invalid overlay", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    overlays:\n      ref: \"body.ref ==\"\n", "rules[0]: test: overlays[ref]: failed to compile expression"},
+		{"invalid overlay key", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    overlays:\n      \"a=b\": body.ref\n", `rules[0]: test: overlays: invalid key "a=b"`},
+		{"invalid template", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    templates:\n      name: \"{{ .Repo \"\n", "rules[0]: test: templates[name]: failed to parse template"},
+		{"invalid template key", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    templates:\n      \"a=b\": \"{{ .Repo }}\"\n", `rules[0]: test: templates: invalid key "a=b"`},
+		{"invalid enrichment key", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    enrichment:\n      \"a*b\": dev\n", `rules[0]: test: enrichment: invalid key "a*b"`},
+		{"nested enrichment key", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    enrichment:\n      a.b: dev\n", `rules[0]: test: enrichment: invalid key "a.b"`},
+		{"invalid output key", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    outputKey: build.info\n", `rules[0]: test: outputKey: invalid key "build.info"`},
+		{"duplicate name", "rules:\n  - name: test\n    event: push\n    repos: [a/b]\n  - name: test\n    event: push\n    repos: [a/b]\n", `rules[1]: duplicate name "test", also used by rules[0]`},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.rules))
+			if err == nil {
+				t.Fatal("expected an error parsing the rules")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse() got error %q, wanted %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rules.yaml")
+	if err := ioutil.WriteFile(path, []byte(testRules), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rs, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rs.Get("pr-ci") == nil {
+		t.Fatal("LoadFile() did not load the pr-ci rule")
+	}
+}
+
+func TestLoadFileWithMissingFile(t *testing.T) {
+	_, err := LoadFile("/does/not/exist.yaml")
+	if err == nil {
+		t.Fatal("expected an error loading a missing file")
+	}
+}
+
+func TestRuleHeaders(t *testing.T) {
+	rs, err := Parse([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headerTests := []struct {
+		name string
+		want http.Header
+	}{
+		{"dev-ci", http.Header{
+			"Push-Repo":                    []string{"testing/repo1,testing/repo2"},
+			"Push-Ref":                     []string{"master,glob:release/*"},
+			"Pushexclude-Ref":              []string{"release/broken"},
+			"Push-Paths":                   []string{"services/api/**"},
+			"Interceptor-Skip-Markers":     []string{"[skip dev]"},
+			"Interceptor-Skip-All-Commits": []string{"true"},
+		}},
+		{"pr-ci", http.Header{
+			"Pullrequest-Repo":           []string{"testing/repo1"},
+			"Pullrequest-Action":         []string{"opened,synchronize"},
+			"Pullrequest-Sender":         []string{"octocat"},
+			"Pullrequest-Base-Ref":       []string{"main,glob:release/*"},
+			"Pullrequest-Labels":         []string{"run-e2e"},
+			"Pullrequest-Include-Drafts": []string{"true"},
+			"Pullrequest-Fork-Policy":    []string{"ok-to-test"},
+			"Interceptor-Skip-Disabled":  []string{"true"},
+			"Interceptor-Filter":         []string{"!body.pull_request.draft"},
+			"Interceptor-Overlay":        []string{"base=body.pull_request.base.ref", "number=body.pull_request.number"},
+			"Interceptor-Template":       []string{"image_tag={{ .Repo | dns1123 }}-{{ .ShortSHA }}", "name=pr-{{ .Number }}"},
+			"Interceptor-Output-Key":     []string{"build"},
+		}},
+	}
+
+	for _, tt := range headerTests {
+		if h := rs.Get(tt.name).Headers(); !reflect.DeepEqual(h, tt.want) {
+			t.Errorf("Headers() for %s got %#v, wanted %#v", tt.name, h, tt.want)
+		}
+	}
+}
+
+func TestParseWithCommaInRegex(t *testing.T) {
+	rs, err := Parse([]byte("rules:\n  - name: test\n    event: push\n    repos: [a/b]\n    refs: [\"regex:^v[0-9]{1,3}$\"]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := rs.Get("test").Headers().Get("Push-Ref"); h != "regex:^v[0-9]{1,3}$" {
+		t.Fatalf("got Push-Ref %q", h)
+	}
+}
@@ -0,0 +1,119 @@
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/bigkevmcd/interceptor/pkg/logging"
+)
+
+// Watcher is a Source that reloads the rules from a file when it changes.
+//
+// Changes are detected by polling the file, which works reliably for files
+// mounted from ConfigMaps, which are updated by swapping symlinks.
+//
+// If the changed file is not valid, the previous rules are kept.
+type Watcher struct {
+	path    string
+	current atomic.Value
+
+	mu        sync.Mutex
+	content   []byte
+	failed    string
+	lastError error
+}
+
+// NewWatcher creates a Watcher, the rules in the file must be valid.
+func NewWatcher(path string) (*Watcher, error) {
+	w := &Watcher{path: path}
+	if _, err := w.Reload(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// RuleSet implements the Source interface, returning the currently active
+// rules.
+//
+// Callers should get the rules once per request, so that the request is
+// completed with the same rules, even if they are reloaded.
+func (w *Watcher) RuleSet() *RuleSet {
+	return w.current.Load().(*RuleSet)
+}
+
+// LastError returns the error from the last failed reload, or nil if the
+// last reload succeeded.
+func (w *Watcher) LastError() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastError
+}
+
+// Reload reads the file, and if it has changed, parses and validates it,
+// and swaps in the new rules.
+//
+// It returns true if the rules were replaced.
+//
+// If the content of the file is the same as the last content that failed
+// to parse, it's not parsed again, and no error is returned, LastError
+// continues to return the original error until the file changes.
+func (w *Watcher) Reload() (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	b, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		w.lastError = fmt.Errorf("failed to read rules file %s: %w", w.path, err)
+		return false, w.lastError
+	}
+	if w.content != nil && bytes.Equal(b, w.content) {
+		w.failed = ""
+		w.lastError = nil
+		return false, nil
+	}
+	version := contentVersion(b)
+	if version == w.failed {
+		return false, nil
+	}
+	rs, err := Parse(b)
+	if err != nil {
+		w.failed = version
+		w.lastError = fmt.Errorf("invalid rules file %s: %w", w.path, err)
+		return false, w.lastError
+	}
+	w.content = b
+	w.failed = ""
+	w.lastError = nil
+	w.current.Store(rs)
+	return true, nil
+}
+
+// Watch polls the file for changes at the interval until the stop channel
+// is closed.
+func (w *Watcher) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			changed, err := w.Reload()
+			if err != nil {
+				logging.Default().Error("failed to reload rules",
+					zap.String("version", w.RuleSet().Version()), zap.Error(err))
+				continue
+			}
+			if changed {
+				logging.Default().Info("reloaded rules", zap.Int("rules", w.RuleSet().Len()),
+					zap.String("path", w.path), zap.String("version", w.RuleSet().Version()))
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package rules
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testUpdatedRules = `
+rules:
+  - name: prod-ci
+    event: push
+    repos: [testing/repo1]
+`
+
+func TestWatcherReload(t *testing.T) {
+	path := writeRulesFile(t, testRules)
+	w, err := NewWatcher(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := w.RuleSet()
+	if original.Get("dev-ci") == nil {
+		t.Fatal("NewWatcher() did not load the dev-ci rule")
+	}
+
+	changed, err := w.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed {
+		t.Fatal("Reload() with an unchanged file got true, wanted false")
+	}
+
+	if err := ioutil.WriteFile(path, []byte(testUpdatedRules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err = w.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Fatal("Reload() with a changed file got false, wanted true")
+	}
+	if w.RuleSet().Get("prod-ci") == nil {
+		t.Fatal("Reload() did not load the prod-ci rule")
+	}
+	if w.RuleSet().Version() == original.Version() {
+		t.Fatalf("Reload() did not change the version %s", original.Version())
+	}
+	if original.Get("dev-ci") == nil {
+		t.Fatal("Reload() modified the original rules")
+	}
+}
+
+func TestWatcherReloadWithInvalidRules(t *testing.T) {
+	path := writeRulesFile(t, testRules)
+	w, err := NewWatcher(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := w.RuleSet().Version()
+
+	if err := ioutil.WriteFile(path, []byte("rules:\n  - name: test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.Reload()
+	if err == nil {
+		t.Fatal("expected an error reloading invalid rules")
+	}
+	if w.LastError() == nil {
+		t.Fatal("LastError() got nil after a failed reload")
+	}
+	if v := w.RuleSet().Version(); v != version {
+		t.Fatalf("Reload() replaced version %s with %s", version, v)
+	}
+	changed, err := w.Reload()
+	if changed || err != nil {
+		t.Fatalf("Reload() of the same invalid rules got %v, %v, wanted false, nil", changed, err)
+	}
+	if w.LastError() == nil {
+		t.Fatal("LastError() got nil after reloading the same invalid rules")
+	}
+
+	if err := ioutil.WriteFile(path, []byte(testUpdatedRules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if w.LastError() != nil {
+		t.Fatalf("LastError() got %s after a successful reload", w.LastError())
+	}
+}
+
+func TestNewWatcherWithInvalidRules(t *testing.T) {
+	path := writeRulesFile(t, "rules:\n  - name: test\n")
+
+	_, err := NewWatcher(path)
+	if err == nil {
+		t.Fatal("expected an error with invalid rules")
+	}
+}
+
+func TestWatcherWatch(t *testing.T) {
+	path := writeRulesFile(t, testRules)
+	w, err := NewWatcher(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go w.Watch(time.Millisecond*10, stop)
+
+	if err := ioutil.WriteFile(path, []byte(testUpdatedRules), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second * 5)
+	for w.RuleSet().Get("prod-ci") == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Watch() did not reload the rules")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func writeRulesFile(t *testing.T, content string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "rules.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// ErrNoSecret is returned when there is no secret configured for a
+// repository.
+var ErrNoSecret = errors.New("no secret configured")
+
+// Getter looks up the secret used to sign hooks for a repository.
+type Getter interface {
+	Secret(repo string) ([]byte, error)
+}
+
+// Store is a Getter with an optional default secret, and secrets for
+// specific repositories.
+type Store struct {
+	defaultSecret []byte
+	repoSecrets   map[string][]byte
+}
+
+// New creates a Store, if no secret is configured for a specific
+// repository then the default secret is returned.
+func New(defaultSecret []byte, repoSecrets map[string][]byte) *Store {
+	if repoSecrets == nil {
+		repoSecrets = map[string][]byte{}
+	}
+	return &Store{defaultSecret: defaultSecret, repoSecrets: repoSecrets}
+}
+
+// Secret implements the Getter interface.
+func (s *Store) Secret(repo string) ([]byte, error) {
+	if secret, ok := s.repoSecrets[repo]; ok {
+		return secret, nil
+	}
+	if len(s.defaultSecret) > 0 {
+		return s.defaultSecret, nil
+	}
+	return nil, fmt.Errorf("%w for repo %q", ErrNoSecret, repo)
+}
+
+// Empty returns true if there are no secrets configured.
+func (s *Store) Empty() bool {
+	return len(s.defaultSecret) == 0 && len(s.repoSecrets) == 0
+}
+
+// FromEnv reads a secret from the named environment variable.
+//
+// If the variable is not set, this returns nil.
+func FromEnv(name string) []byte {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil
+	}
+	return []byte(v)
+}
+
+// FromFile reads a secret from a file, trimming any surrounding whitespace.
+func FromFile(path string) ([]byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret file %s: %w", path, err)
+	}
+	return bytes.TrimSpace(b), nil
+}
+
+// RepoSecretsFromFile reads a JSON object mapping repository names to
+// secrets.
+//
+// e.g. {"tektoncd/triggers": "secret1", "tektoncd/pipeline": "secret2"}
+func RepoSecretsFromFile(path string) (map[string][]byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file %s: %w", path, err)
+	}
+	parsed := map[string]string{}
+	if err := json.Unmarshal(b, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets file %s: %w", path, err)
+	}
+	repoSecrets := map[string][]byte{}
+	for k, v := range parsed {
+		if v == "" {
+			return nil, fmt.Errorf("empty secret for repo %q in %s", k, path)
+		}
+		repoSecrets[k] = []byte(v)
+	}
+	return repoSecrets, nil
+}
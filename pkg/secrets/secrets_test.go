@@ -0,0 +1,101 @@
+package secrets
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStoreSecret(t *testing.T) {
+	s := New([]byte("default"), map[string][]byte{"testing/repo": []byte("repo")})
+
+	secretTests := []struct {
+		repo string
+		want string
+	}{
+		{"testing/repo", "repo"},
+		{"testing/other", "default"},
+	}
+
+	for _, tt := range secretTests {
+		secret, err := s.Secret(tt.repo)
+		if err != nil {
+			t.Errorf("Secret(%q) failed: %s", tt.repo, err)
+			continue
+		}
+		if string(secret) != tt.want {
+			t.Errorf("Secret(%q) got %s, wanted %s", tt.repo, secret, tt.want)
+		}
+	}
+}
+
+func TestStoreSecretWithNoDefault(t *testing.T) {
+	s := New(nil, map[string][]byte{"testing/repo": []byte("repo")})
+
+	_, err := s.Secret("testing/other")
+	if !errors.Is(err, ErrNoSecret) {
+		t.Fatalf("Secret() got %v, wanted %v", err, ErrNoSecret)
+	}
+}
+
+func TestStoreEmpty(t *testing.T) {
+	if !New(nil, nil).Empty() {
+		t.Fatal("Empty() got false, wanted true")
+	}
+	if New([]byte("default"), nil).Empty() {
+		t.Fatal("Empty() got true, wanted false")
+	}
+}
+
+func TestFromFile(t *testing.T) {
+	path := writeTempFile(t, "secret-value\n")
+
+	secret, err := FromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(secret) != "secret-value" {
+		t.Fatalf("FromFile() got %q, wanted %q", secret, "secret-value")
+	}
+}
+
+func TestRepoSecretsFromFile(t *testing.T) {
+	path := writeTempFile(t, `{"testing/repo1": "secret1", "testing/repo2": "secret2"}`)
+
+	secrets, err := RepoSecretsFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]byte{
+		"testing/repo1": []byte("secret1"),
+		"testing/repo2": []byte("secret2"),
+	}
+	if !reflect.DeepEqual(secrets, want) {
+		t.Fatalf("RepoSecretsFromFile() got %#v, wanted %#v", secrets, want)
+	}
+}
+
+func TestRepoSecretsFromFileWithEmptySecret(t *testing.T) {
+	path := writeTempFile(t, `{"testing/repo1": ""}`)
+
+	_, err := RepoSecretsFromFile(path)
+	if err == nil {
+		t.Fatal("expected an error with an empty secret")
+	}
+}
+
+func writeTempFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "secret")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
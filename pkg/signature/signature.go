@@ -0,0 +1,127 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+const (
+	// SHA256Header is the header GitHub uses for the HMAC-SHA256 signature.
+	SHA256Header = "X-Hub-Signature-256"
+	// SHA1Header is the legacy header GitHub uses for the HMAC-SHA1 signature.
+	SHA1Header = "X-Hub-Signature"
+)
+
+var (
+	// ErrMissingSignature is returned when the request has no signature.
+	ErrMissingSignature = errors.New("missing signature")
+	// ErrInvalidSignature is returned when the signature doesn't match the
+	// body.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// These are the algorithms that signatures can be prefixed with.
+const (
+	SHA256 = "sha256"
+	SHA1   = "sha1"
+)
+
+// ValidateRequest checks the GitHub signature headers against the body.
+//
+// The X-Hub-Signature-256 header is preferred, falling back to the legacy
+// X-Hub-Signature header if it's not present, the signature must use the
+// algorithm for the header it arrived in, i.e. "sha256=" for
+// X-Hub-Signature-256 and "sha1=" for X-Hub-Signature.
+func ValidateRequest(r *http.Request, body, secret []byte) error {
+	if sig := r.Header.Get(SHA256Header); sig != "" {
+		return ValidateAlgorithm(SHA256, sig, body, secret)
+	}
+	if sig := r.Header.Get(SHA1Header); sig != "" {
+		return ValidateAlgorithm(SHA1, sig, body, secret)
+	}
+	return ErrMissingSignature
+}
+
+// Validate checks that a signature of the form "sha256=<hex digest>" or
+// "sha1=<hex digest>" is the HMAC of the body with the secret.
+//
+// The algorithm is taken from the signature, use ValidateAlgorithm if the
+// algorithm is known from where the signature came from.
+//
+// The comparison is done in constant time.
+func Validate(sig string, body, secret []byte) error {
+	parts := strings.SplitN(sig, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("%w: malformed signature %q", ErrInvalidSignature, sig)
+	}
+	return ValidateAlgorithm(parts[0], sig, body, secret)
+}
+
+// ValidateAlgorithm checks that a signature of the form
+// "<algorithm>=<hex digest>" is the HMAC of the body with the secret, using
+// the algorithm, which must be SHA256 or SHA1.
+//
+// Signatures prefixed with a different algorithm are rejected.
+//
+// The comparison is done in constant time.
+func ValidateAlgorithm(algorithm, sig string, body, secret []byte) error {
+	parts := strings.SplitN(sig, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("%w: malformed signature %q", ErrInvalidSignature, sig)
+	}
+	var h func() hash.Hash
+	switch algorithm {
+	case SHA256:
+		h = sha256.New
+	case SHA1:
+		h = sha1.New
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, algorithm)
+	}
+	if parts[0] != algorithm {
+		return fmt.Errorf("%w: got algorithm %q, wanted %q", ErrInvalidSignature, parts[0], algorithm)
+	}
+	return ValidateHex(h, parts[1], body, secret)
+}
+
+// ValidateHex checks that the hex-encoded digest is the HMAC of the body
+// with the secret, using the provided hash.
+func ValidateHex(h func() hash.Hash, digest string, body, secret []byte) error {
+	decoded, err := hex.DecodeString(digest)
+	if err != nil {
+		return fmt.Errorf("%w: failed to decode digest: %s", ErrInvalidSignature, err)
+	}
+	if !hmac.Equal(decoded, Sign(h, body, secret)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Sign returns the HMAC of the body with the secret.
+func Sign(h func() hash.Hash, body, secret []byte) []byte {
+	mac := hmac.New(h, secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// ValidateToken checks that a plain token (as sent by GitLab) matches the
+// secret.
+//
+// The comparison is done in constant time.
+func ValidateToken(token string, secret []byte) error {
+	if token == "" {
+		return ErrMissingSignature
+	}
+	if subtle.ConstantTimeCompare([]byte(token), secret) != 1 {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+var (
+	testBody   = []byte(`{"testing":"value"}`)
+	testSecret = []byte("secret-value")
+)
+
+func TestValidate(t *testing.T) {
+	sha256Sig := "sha256=" + hex.EncodeToString(Sign(sha256.New, testBody, testSecret))
+	sha1Sig := "sha1=" + hex.EncodeToString(Sign(sha1.New, testBody, testSecret))
+	otherSig := "sha256=" + hex.EncodeToString(Sign(sha256.New, testBody, []byte("other")))
+
+	sigTests := []struct {
+		sig     string
+		wantErr error
+	}{
+		{sha256Sig, nil},
+		{sha1Sig, nil},
+		{otherSig, ErrInvalidSignature},
+		{"sha256=zz", ErrInvalidSignature},
+		{"md5=abcdef", ErrInvalidSignature},
+		{"abcdef", ErrInvalidSignature},
+	}
+
+	for _, tt := range sigTests {
+		err := Validate(tt.sig, testBody, testSecret)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("Validate(%q) got %v, wanted %v", tt.sig, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	sha256Sig := "sha256=" + hex.EncodeToString(Sign(sha256.New, testBody, testSecret))
+	sha1Sig := "sha1=" + hex.EncodeToString(Sign(sha1.New, testBody, testSecret))
+
+	headerTests := []struct {
+		headers map[string]string
+		wantErr error
+	}{
+		{map[string]string{SHA256Header: sha256Sig}, nil},
+		{map[string]string{SHA1Header: sha1Sig}, nil},
+		{map[string]string{SHA256Header: sha256Sig, SHA1Header: "sha1=000000"}, nil},
+		{map[string]string{SHA256Header: "sha256=000000", SHA1Header: sha1Sig}, ErrInvalidSignature},
+		{map[string]string{SHA256Header: sha1Sig}, ErrInvalidSignature},
+		{map[string]string{SHA1Header: sha256Sig}, ErrInvalidSignature},
+		{map[string]string{}, ErrMissingSignature},
+	}
+
+	for _, tt := range headerTests {
+		r, _ := http.NewRequest("POST", "/", bytes.NewReader(testBody))
+		for k, v := range tt.headers {
+			r.Header.Add(k, v)
+		}
+		err := ValidateRequest(r, testBody, testSecret)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("ValidateRequest(%v) got %v, wanted %v", tt.headers, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateAlgorithm(t *testing.T) {
+	sha256Sig := "sha256=" + hex.EncodeToString(Sign(sha256.New, testBody, testSecret))
+	sha1Sig := "sha1=" + hex.EncodeToString(Sign(sha1.New, testBody, testSecret))
+
+	sigTests := []struct {
+		algorithm string
+		sig       string
+		wantErr   error
+	}{
+		{SHA256, sha256Sig, nil},
+		{SHA1, sha1Sig, nil},
+		{SHA256, sha1Sig, ErrInvalidSignature},
+		{SHA1, sha256Sig, ErrInvalidSignature},
+		{"md5", "md5=abcdef", ErrInvalidSignature},
+		{SHA256, "abcdef", ErrInvalidSignature},
+	}
+
+	for _, tt := range sigTests {
+		err := ValidateAlgorithm(tt.algorithm, tt.sig, testBody, testSecret)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("ValidateAlgorithm(%q, %q) got %v, wanted %v", tt.algorithm, tt.sig, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateToken(t *testing.T) {
+	tokenTests := []struct {
+		token   string
+		wantErr error
+	}{
+		{"secret-value", nil},
+		{"other-value", ErrInvalidSignature},
+		{"secret-valu", ErrInvalidSignature},
+		{"", ErrMissingSignature},
+	}
+
+	for _, tt := range tokenTests {
+		err := ValidateToken(tt.token, testSecret)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("ValidateToken(%q) got %v, wanted %v", tt.token, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Package skip finds markers like "[skip ci]" in commit messages and pull
+// request titles, that ask for the pipelines for a hook to be skipped.
+package skip
+
+import (
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/headers"
+	"github.com/bigkevmcd/interceptor/pkg/matcher"
+)
+
+const (
+	// MarkersHeader is a comma-separated list of markers that replace the
+	// DefaultMarkers.
+	MarkersHeader = "Interceptor-Skip-Markers"
+
+	// AllCommitsHeader checks the messages of all the commits in a push for
+	// markers if it's "true", rather than just the head commit.
+	AllCommitsHeader = "Interceptor-Skip-All-Commits"
+
+	// DisabledHeader disables skipping if it's "true".
+	DisabledHeader = "Interceptor-Skip-Disabled"
+)
+
+// DefaultMarkers are the markers that are used if none are configured.
+var DefaultMarkers = []string{"[skip ci]", "[ci skip]", "[no ci]"}
+
+// Options configures how events are checked for skip markers.
+type Options struct {
+	// Markers are matched case-insensitively.
+	Markers []string
+	// AllCommits checks all the commits in a push, not just the head
+	// commit.
+	AllCommits bool
+	// Disabled turns off skipping.
+	Disabled bool
+}
+
+// FromRequest returns the Options configured by the headers in the request.
+func FromRequest(r *http.Request) *Options {
+	markers := headers.SplitList(r.Header.Get(MarkersHeader))
+	if len(markers) == 0 {
+		markers = DefaultMarkers
+	}
+	return &Options{
+		Markers:    markers,
+		AllCommits: r.Header.Get(AllCommitsHeader) == "true",
+		Disabled:   r.Header.Get(DisabledHeader) == "true",
+	}
+}
+
+// Marker returns the first marker found in the event, or an empty string if
+// there are none, or skipping is disabled.
+//
+// The pull request title and description, and the head commit message are
+// checked, and if AllCommits is set, the messages of the other commits.
+func (o *Options) Marker(e *event.Event) string {
+	if o.Disabled {
+		return ""
+	}
+	texts := []string{e.Title, e.Description, e.HeadCommitMessage}
+	if o.AllCommits {
+		texts = append(texts, e.CommitMessages...)
+	}
+	for _, t := range texts {
+		t = strings.ToLower(t)
+		for _, m := range o.Markers {
+			if strings.Contains(t, strings.ToLower(m)) {
+				return m
+			}
+		}
+	}
+	return ""
+}
+
+// Matcher returns a matcher that rejects events with a skip marker.
+func (o *Options) Matcher() matcher.Matcher {
+	return matcher.Func(func(e *event.Event) (bool, decision.Reason) {
+		if o.Marker(e) != "" {
+			return false, decision.SkipMarker
+		}
+		return true, decision.Matched
+	})
+}
+
+// DecisionFields returns the fields for logging the decision for an event,
+// if the event was rejected because of a skip marker, the marker is added.
+func (o *Options) DecisionFields(e *event.Event, reason decision.Reason, fields ...zap.Field) []zap.Field {
+	if reason == decision.SkipMarker {
+		fields = append(fields, zap.String("skip_marker", o.Marker(e)))
+	}
+	return fields
+}
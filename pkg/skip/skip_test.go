@@ -0,0 +1,72 @@
+package skip
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bigkevmcd/interceptor/pkg/decision"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+)
+
+func TestMarker(t *testing.T) {
+	markerTests := []struct {
+		name    string
+		headers map[string]string
+		event   *event.Event
+		want    string
+	}{
+		{"no marker", nil, &event.Event{HeadCommitMessage: "Fix the tests"}, ""},
+		{"head commit", nil, &event.Event{HeadCommitMessage: "Update the docs [skip ci]"}, "[skip ci]"},
+		{"case insensitive", nil, &event.Event{HeadCommitMessage: "Update the docs [CI SKIP]"}, "[ci skip]"},
+		{"pull request title", nil, &event.Event{Title: "[no ci] Update the docs"}, "[no ci]"},
+		{"pull request description", nil, &event.Event{Description: "Only docs.\n\n[skip ci]"}, "[skip ci]"},
+		{"other commit", nil,
+			&event.Event{HeadCommitMessage: "Fix the tests", CommitMessages: []string{"[skip ci] wip", "Fix the tests"}}, ""},
+		{"all commits", map[string]string{AllCommitsHeader: "true"},
+			&event.Event{HeadCommitMessage: "Fix the tests", CommitMessages: []string{"[skip ci] wip", "Fix the tests"}}, "[skip ci]"},
+		{"configured markers", map[string]string{MarkersHeader: "[skip tekton], ***NO_CI***"},
+			&event.Event{HeadCommitMessage: "Update the docs ***NO_CI***"}, "***NO_CI***"},
+		{"configured markers replace defaults", map[string]string{MarkersHeader: "[skip tekton]"},
+			&event.Event{HeadCommitMessage: "Update the docs [skip ci]"}, ""},
+		{"disabled", map[string]string{DisabledHeader: "true"},
+			&event.Event{HeadCommitMessage: "Update the docs [skip ci]"}, ""},
+	}
+
+	for _, tt := range markerTests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest("POST", "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			if m := FromRequest(r).Marker(tt.event); m != tt.want {
+				t.Fatalf("Marker() got %q, wanted %q", m, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	o := &Options{Markers: DefaultMarkers}
+
+	if ok, reason := o.Matcher().Match(&event.Event{HeadCommitMessage: "[skip ci]"}); ok || reason != decision.SkipMarker {
+		t.Errorf("Match() got %v, %q, wanted false, %q", ok, reason, decision.SkipMarker)
+	}
+	if ok, reason := o.Matcher().Match(&event.Event{HeadCommitMessage: "Fix the tests"}); !ok || reason != decision.Matched {
+		t.Errorf("Match() got %v, %q, wanted true, %q", ok, reason, decision.Matched)
+	}
+}
+
+func TestDecisionFields(t *testing.T) {
+	r, _ := http.NewRequest("POST", "/", nil)
+	o := FromRequest(r)
+	e := &event.Event{HeadCommitMessage: "[skip ci]"}
+
+	if f := o.DecisionFields(e, decision.RepoMismatch); len(f) != 0 {
+		t.Errorf("DecisionFields() got %d fields, wanted none", len(f))
+	}
+	f := o.DecisionFields(e, decision.SkipMarker)
+	if len(f) != 1 || f[0].Key != "skip_marker" || f[0].String != "[skip ci]" {
+		t.Errorf("DecisionFields() got %#v, wanted the skip_marker", f)
+	}
+}
@@ -0,0 +1,140 @@
+// Package templates renders text/template templates over normalised hook
+// events.
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/bigkevmcd/interceptor/pkg/cache"
+	"github.com/bigkevmcd/interceptor/pkg/event"
+	"github.com/bigkevmcd/interceptor/pkg/git"
+	"github.com/bigkevmcd/interceptor/pkg/naming"
+)
+
+// Funcs are the functions that are available in templates.
+//
+//    dns1123 - converts a string to a valid DNS-1123 label, e.g. for
+//    Kubernetes resource names, see naming.DNS1123Label
+//    labelValue - converts a string to a valid Kubernetes label value, see
+//    naming.LabelValue
+//    lower - converts a string to lowercase
+//    truncate - truncates a string to a number of characters e.g.
+//    {{ .Branch | truncate 20 }}
+//    trimPrefix - removes a prefix from a string e.g.
+//    {{ .Branch | trimPrefix "feature/" }}
+var Funcs = template.FuncMap{
+	"dns1123":    naming.DNS1123Label,
+	"labelValue": naming.LabelValue,
+	"lower":      strings.ToLower,
+	"truncate":   truncate,
+	"trimPrefix": trimPrefix,
+}
+
+// Model is the data that templates are rendered with.
+//
+// The fields of the event are available directly e.g. {{ .Repo }}, along with
+// the values that were intercepted e.g. {{ .Intercepted.short_sha }}.
+type Model struct {
+	event.Event
+	// Branch is the branch for pushes to branches, and the head branch for
+	// pull requests.
+	Branch string
+	// Tag is the tag for tag pushes.
+	Tag string
+	// ShortSHA is the shortened version of the SHA.
+	ShortSHA string
+	// Intercepted are the values that were intercepted from the hook.
+	Intercepted map[string]interface{}
+}
+
+// NewModel creates the model for an event and the values that were
+// intercepted from it, the event can be nil if the hook has no normalised
+// event.
+func NewModel(e *event.Event, intercepted map[string]interface{}) *Model {
+	m := &Model{Intercepted: intercepted}
+	if e == nil {
+		return m
+	}
+	m.Event = *e
+	m.ShortSHA = git.ShortenSHA(e.SHA)
+	m.Branch = e.BranchName()
+	if tag, ok := git.TagName(e.Ref); ok && e.Type == event.Push {
+		m.Tag = tag
+	}
+	return m
+}
+
+// Renderer renders templates with the Funcs.
+//
+// Templates are parsed the first time they are rendered, and up to CacheSize
+// of the parsed templates are cached.
+type Renderer struct {
+	templates *cache.LRU
+}
+
+// CacheSize is the number of parsed templates that a Renderer caches, the
+// templates come from request headers, so the cache is bounded.
+const CacheSize = 500
+
+// New creates a Renderer.
+func New() *Renderer {
+	return &Renderer{templates: cache.New(CacheSize)}
+}
+
+// Render renders a template with the model.
+//
+// Referring to a missing key in the intercepted values is an error.
+func (r *Renderer) Render(text string, m *Model) (string, error) {
+	t, err := r.parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, m); err != nil {
+		return "", fmt.Errorf("failed to render template %q: %w", text, err)
+	}
+	return b.String(), nil
+}
+
+// Check parses a template and returns any error.
+func Check(text string) error {
+	_, err := parse(text)
+	return err
+}
+
+func (r *Renderer) parse(text string) (*template.Template, error) {
+	if t, ok := r.templates.Get(text); ok {
+		return t.(*template.Template), nil
+	}
+	t, err := parse(text)
+	if err != nil {
+		return nil, err
+	}
+	r.templates.Add(text, t)
+	return t, nil
+}
+
+func parse(text string) (*template.Template, error) {
+	t, err := template.New("").Funcs(Funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %q: %w", text, err)
+	}
+	return t, nil
+}
+
+// truncate returns the first n characters of s, the arguments are in this
+// order so that it can be used in pipelines.
+func truncate(n int, s string) string {
+	runes := []rune(s)
+	if n < 0 || len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+func trimPrefix(prefix, s string) string {
+	return strings.TrimPrefix(s, prefix)
+}
@@ -0,0 +1,93 @@
+package templates
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bigkevmcd/interceptor/pkg/event"
+)
+
+func TestRender(t *testing.T) {
+	push := &event.Event{
+		Type: event.Push,
+		Repo: "bigkevmcd/interceptor",
+		Ref:  "feature/Login_Page",
+		SHA:  "6a6bcddc365ca3a38c9055a603c9590a7fae7ca6",
+	}
+	renderTests := []struct {
+		name     string
+		template string
+		event    *event.Event
+		want     string
+	}{
+		{"image tag", "{{ .Repo | dns1123 }}-{{ .Branch | dns1123 }}-{{ .ShortSHA }}", push, "bigkevmcd-interceptor-feature-login-page-6a6bcd"},
+		{"lower", "{{ .Branch | lower }}", push, "feature/login_page"},
+		{"labelValue", "{{ .Branch | labelValue }}", push, "feature-login_page"},
+		{"truncate", "{{ .Branch | truncate 7 }}", push, "feature"},
+		{"trimPrefix", `{{ .Branch | trimPrefix "feature/" }}`, push, "Login_Page"},
+		{"intercepted", "{{ .Intercepted.fullname }}", push, "bigkevmcd/interceptor"},
+		{"tag", "{{ .Tag }}{{ .Branch }}", &event.Event{Type: event.Push, Ref: "refs/tags/v1.0.0"}, "v1.0.0"},
+		{"pull request", "pr-{{ .Number }}-{{ .Branch }}", &event.Event{Type: event.PullRequest, Number: 12, HeadRef: "fix"}, "pr-12-fix"},
+		{"no event", "{{ .Intercepted.fullname }}{{ .Branch }}", nil, "bigkevmcd/interceptor"},
+	}
+
+	r := New()
+	for _, tt := range renderTests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewModel(tt.event, map[string]interface{}{"fullname": "bigkevmcd/interceptor"})
+			got, err := r.Render(tt.template, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("Render() got %q, wanted %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	errorTests := []struct {
+		name     string
+		template string
+	}{
+		{"invalid template", "{{ .Repo "},
+		{"missing field", "{{ .Unknown }}"},
+		{"missing intercepted value", "{{ .Intercepted.unknown }}"},
+		{"unknown function", "{{ .Repo | upper }}"},
+	}
+
+	r := New()
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := r.Render(tt.template, NewModel(&event.Event{}, map[string]interface{}{}))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := Check("{{ .Repo | dns1123 }}"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check("{{ .Repo "); err == nil {
+		t.Fatal("expected an error with an invalid template")
+	}
+}
+
+func TestTemplateCacheIsBounded(t *testing.T) {
+	r := New()
+	m := NewModel(&event.Event{Repo: "testing/testing"}, nil)
+
+	for i := 0; i < CacheSize+10; i++ {
+		if _, err := r.Render(fmt.Sprintf("{{ .Repo }}-%d", i), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if count := r.templates.Len(); count != CacheSize {
+		t.Fatalf("got %d cached templates, wanted %d", count, CacheSize)
+	}
+}